@@ -0,0 +1,464 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiMetricsEndpoint = "/api/v1/metrics"
+	apiSeatsEndpoint   = "/api/v1/seats"
+)
+
+// apiFilter selects the targets, days and breakdown entries returned by the JSON API. Empty fields match everything.
+type apiFilter struct {
+	since, until string
+	team         string
+	editor       string
+	language     string
+	model        string
+}
+
+// apiFilterFromQuery parses the since, until, team, editor, language and model query parameters
+func apiFilterFromQuery(r *http.Request) (apiFilter, error) {
+	query := r.URL.Query()
+	f := apiFilter{
+		since:    query.Get("since"),
+		until:    query.Get("until"),
+		team:     query.Get("team"),
+		editor:   query.Get("editor"),
+		language: query.Get("language"),
+		model:    query.Get("model"),
+	}
+
+	for name, day := range map[string]string{"since": f.since, "until": f.until} {
+		if day == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			return apiFilter{}, fmt.Errorf("%s must be a date in YYYY-MM-DD format", name)
+		}
+	}
+	if f.since != "" && f.until != "" && f.since > f.until {
+		return apiFilter{}, fmt.Errorf("since must not be after until")
+	}
+
+	return f, nil
+}
+
+// matches reports whether value is selected by filter, ignoring case
+func matches(filter, value string) bool {
+	return filter == "" || strings.EqualFold(filter, value)
+}
+
+// matchesIfSet is matches for a breakdown dimension that may be absent; an empty value is always selected
+func matchesIfSet(filter, value string) bool {
+	return value == "" || matches(filter, value)
+}
+
+// apiMetricsResponse is the body of the JSON API
+type apiMetricsResponse struct {
+	Targets []apiTarget `json:"targets"`
+}
+
+// apiTarget is the snapshot of one target
+type apiTarget struct {
+	Org        string `json:"org,omitempty"`
+	Team       string `json:"team,omitempty"`
+	Enterprise string `json:"enterprise,omitempty"`
+	Scope      string `json:"scope"`
+
+	// When the snapshot was fetched, and the error of the most recent fetch
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+
+	Days []apiDay `json:"days"`
+}
+
+// apiDay is a day of metrics as returned by GitHub together with the rates derived from it
+type apiDay struct {
+	CopilotDayMetrics
+	Rates apiRates `json:"rates"`
+}
+
+// apiRates holds the derived rates of a day; rates without suggestions are 0, like the exported gauges
+type apiRates struct {
+	AcceptanceRate      float64 `json:"acceptance_rate"`
+	LinesAcceptanceRate float64 `json:"lines_acceptance_rate"`
+
+	IDECodeCompletions []apiCodeCompletionsRates `json:"ide_code_completions,omitempty"`
+}
+
+// apiCodeCompletionsRates holds the rates of an editor, model and language of the IDE code completions
+type apiCodeCompletionsRates struct {
+	Editor              string  `json:"editor"`
+	Model               string  `json:"model"`
+	Language            string  `json:"language"`
+	AcceptanceRate      float64 `json:"acceptance_rate"`
+	LinesAcceptanceRate float64 `json:"lines_acceptance_rate"`
+}
+
+// ratio divides without failing on an empty denominator
+func ratio(numerator, denominator int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+// newMetricsAPIHandler serves the snapshots of the collectors returned by collectors as JSON. Collectors
+// without background polling fetch on every request, as they do on scrape.
+func newMetricsAPIHandler(collectors func() []*CopilotCollector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filter, err := apiFilterFromQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response := apiMetricsResponse{Targets: []apiTarget{}}
+		for _, c := range collectors() {
+			if filter.team != "" && !strings.EqualFold(filter.team, c.team) {
+				continue
+			}
+			response.Targets = append(response.Targets, c.apiTarget(filter))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding API response: %v", err)
+		}
+	})
+}
+
+// apiTarget returns the collector's stored history, or its snapshot without a store, filtered by filter.
+// Like /metrics, it returns no days once the last successful fetch is older than MAX_STALENESS.
+func (c *CopilotCollector) apiTarget(filter apiFilter) apiTarget {
+	if c.pollInterval == 0 {
+		if err := c.refresh(); err != nil {
+			log.Printf("Error fetching metrics for %s: %v", c.target(), err)
+		}
+	}
+
+	metrics, updated, err := c.currentSnapshot()
+	metrics = c.storedHistory(metrics)
+
+	target := c.target()
+	result := apiTarget{
+		Org:        target.Organization,
+		Team:       target.Team,
+		Enterprise: target.Enterprise,
+		Scope:      target.Scope(),
+		Days:       []apiDay{},
+	}
+	if !updated.IsZero() {
+		result.UpdatedAt = &updated
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	if c.isStale(updated) {
+		return result
+	}
+
+	for _, metric := range metrics {
+		if (filter.since != "" && metric.Day < filter.since) || (filter.until != "" && metric.Day > filter.until) {
+			continue
+		}
+		day := filterDay(metric, filter)
+		result.Days = append(result.Days, apiDay{CopilotDayMetrics: day, Rates: dayRates(day)})
+	}
+	return result
+}
+
+// filterDay returns a copy of metric whose breakdowns only contain the editors, languages and models
+// selected by filter. Breakdowns and breakdown entries without the filtered dimension and the day totals are
+// left unchanged; an entry whose nested entries are all filtered out is dropped.
+func filterDay(metric CopilotDayMetrics, filter apiFilter) CopilotDayMetrics {
+	if filter.editor == "" && filter.language == "" && filter.model == "" {
+		return metric
+	}
+
+	var breakdown []Breakdown
+	for _, b := range metric.Breakdown {
+		if matchesIfSet(filter.editor, b.Editor) && matchesIfSet(filter.language, b.Language) && matchesIfSet(filter.model, b.Model) {
+			breakdown = append(breakdown, b)
+		}
+	}
+	metric.Breakdown = breakdown
+
+	var languages []IDECodeCompletionsLanguage
+	for _, lang := range metric.CopilotIDECodeCompletions.Languages {
+		if matches(filter.language, lang.Name) {
+			languages = append(languages, lang)
+		}
+	}
+	metric.CopilotIDECodeCompletions.Languages = languages
+
+	var completionEditors []IDECodeCompletionsEditor
+	for _, editor := range metric.CopilotIDECodeCompletions.Editors {
+		if !matches(filter.editor, editor.Name) {
+			continue
+		}
+		var models []IDECodeCompletionsModel
+		for _, model := range editor.Models {
+			if !matches(filter.model, model.Name) {
+				continue
+			}
+			var modelLanguages []IDECodeCompletionsModelLanguage
+			for _, lang := range model.Languages {
+				if matches(filter.language, lang.Name) {
+					modelLanguages = append(modelLanguages, lang)
+				}
+			}
+			if len(model.Languages) > 0 && len(modelLanguages) == 0 {
+				continue
+			}
+			model.Languages = modelLanguages
+			models = append(models, model)
+		}
+		if len(editor.Models) > 0 && len(models) == 0 {
+			continue
+		}
+		editor.Models = models
+		completionEditors = append(completionEditors, editor)
+	}
+	metric.CopilotIDECodeCompletions.Editors = completionEditors
+
+	var chatEditors []IDEChatEditor
+	for _, editor := range metric.CopilotIDEChat.Editors {
+		if !matches(filter.editor, editor.Name) {
+			continue
+		}
+		var models []IDEChatModel
+		for _, model := range editor.Models {
+			if matches(filter.model, model.Name) {
+				models = append(models, model)
+			}
+		}
+		if len(editor.Models) > 0 && len(models) == 0 {
+			continue
+		}
+		editor.Models = models
+		chatEditors = append(chatEditors, editor)
+	}
+	metric.CopilotIDEChat.Editors = chatEditors
+
+	var dotcomModels []DotcomChatModel
+	for _, model := range metric.CopilotDotcomChat.Models {
+		if matches(filter.model, model.Name) {
+			dotcomModels = append(dotcomModels, model)
+		}
+	}
+	metric.CopilotDotcomChat.Models = dotcomModels
+
+	repositories := metric.CopilotDotcomPullRequests.Repositories
+	metric.CopilotDotcomPullRequests.Repositories = nil
+	for _, repo := range repositories {
+		var models []DotcomPullRequestsModel
+		for _, model := range repo.Models {
+			if matches(filter.model, model.Name) {
+				models = append(models, model)
+			}
+		}
+		if len(repo.Models) > 0 && len(models) == 0 {
+			continue
+		}
+		repo.Models = models
+		metric.CopilotDotcomPullRequests.Repositories = append(metric.CopilotDotcomPullRequests.Repositories, repo)
+	}
+
+	return metric
+}
+
+// dayRates computes the acceptance rates of a day and of each of its editor, model and language combinations
+func dayRates(metric CopilotDayMetrics) apiRates {
+	rates := apiRates{
+		AcceptanceRate:      ratio(metric.TotalAcceptancesCount, metric.TotalSuggestionsCount),
+		LinesAcceptanceRate: ratio(metric.TotalLinesAccepted, metric.TotalLinesSuggested),
+	}
+
+	for _, editor := range metric.CopilotIDECodeCompletions.Editors {
+		for _, model := range editor.Models {
+			for _, lang := range model.Languages {
+				rates.IDECodeCompletions = append(rates.IDECodeCompletions, apiCodeCompletionsRates{
+					Editor:              labelOrUnknown(editor.Name),
+					Model:               labelOrUnknown(model.Name),
+					Language:            labelOrUnknown(lang.Name),
+					AcceptanceRate:      ratio(lang.TotalCodeAcceptances, lang.TotalCodeSuggestions),
+					LinesAcceptanceRate: ratio(lang.TotalCodeLinesAccepted, lang.TotalCodeLinesSuggested),
+				})
+			}
+		}
+	}
+	return rates
+}
+
+// apiSeat is a seat as returned by the seats API
+type apiSeat struct {
+	Org                     string     `json:"org"`
+	Login                   string     `json:"login"`
+	AssigningTeam           string     `json:"assigning_team,omitempty"`
+	PlanType                string     `json:"plan_type,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	LastActivityAt          *time.Time `json:"last_activity_at"`
+	LastActivityEditor      string     `json:"last_activity_editor,omitempty"`
+	PendingCancellationDate string     `json:"pending_cancellation_date,omitempty"`
+	Enterprise              string     `json:"enterprise,omitempty"`
+}
+
+// apiSeatsResponse is the JSON body of the seats API
+type apiSeatsResponse struct {
+	Seats []apiSeat `json:"seats"`
+}
+
+// apiSeatsCSVHeader is the header row of the seats API's CSV output
+var apiSeatsCSVHeader = []string{
+	"org", "login", "assigning_team", "plan_type", "created_at", "last_activity_at", "last_activity_editor", "pending_cancellation_date", "enterprise",
+}
+
+// csvRecord returns the seat as a CSV row in apiSeatsCSVHeader order
+func (s apiSeat) csvRecord() []string {
+	lastActivity := ""
+	if s.LastActivityAt != nil {
+		lastActivity = s.LastActivityAt.Format(time.RFC3339)
+	}
+	return []string{
+		s.Org, s.Login, s.AssigningTeam, s.PlanType, s.CreatedAt.Format(time.RFC3339), lastActivity, s.LastActivityEditor, s.PendingCancellationDate, s.Enterprise,
+	}
+}
+
+// hashLogin replaces a login by its hex HMAC-SHA256 under key, so reports can be joined across requests
+// without revealing identities
+func hashLogin(key, login string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strings.ToLower(login)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newSeatsAPIHandler serves the seats of the collectors returned by collectors as JSON, or as CSV with
+// format=csv. The org, team and idle_days query parameters select seats by organization, assigning
+// team and days without activity (a number, or never). Logins are hashed with hashKey when it is set.
+func newSeatsAPIHandler(collectors func() []*CopilotCollector, hashKey string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		format := query.Get("format")
+		if format != "" && format != "json" && format != "csv" {
+			http.Error(w, "format must be json or csv", http.StatusBadRequest)
+			return
+		}
+		neverActive := query.Get("idle_days") == "never"
+		idleDays := -1
+		if v := query.Get("idle_days"); v != "" && !neverActive {
+			var err error
+			idleDays, err = strconv.Atoi(v)
+			if err != nil || idleDays < 0 {
+				http.Error(w, "idle_days must be a non-negative number or never", http.StatusBadRequest)
+				return
+			}
+		}
+
+		now := time.Now()
+		seats := []apiSeat{}
+		for _, c := range collectors() {
+			if !c.seatsSupported() {
+				continue
+			}
+			if c.pollInterval == 0 {
+				if err := c.refreshSeats(); err != nil {
+					log.Printf("Error fetching seats for %s: %v", c.target(), err)
+				}
+			}
+			snapshot, updated, _ := c.currentSeats()
+			if snapshot == nil || c.isStale(updated) {
+				continue
+			}
+
+			for _, seat := range snapshot.seats {
+				// Enterprise listings report the organization granting each seat
+				org := c.organization
+				if c.enterprise != "" {
+					org = seat.organization()
+				}
+				if !matches(query.Get("org"), org) {
+					continue
+				}
+
+				team := ""
+				if seat.AssigningTeam != nil {
+					team = seat.AssigningTeam.Slug
+				}
+				if !matches(query.Get("team"), team) {
+					continue
+				}
+				if (neverActive && seat.LastActivityAt != nil) || (idleDays >= 0 && !seat.idleFor(idleDays, now)) {
+					continue
+				}
+
+				login := seat.Assignee.Login
+				if hashKey != "" {
+					login = hashLogin(hashKey, login)
+				}
+				seats = append(seats, apiSeat{
+					Org:                     org,
+					Enterprise:              c.enterprise,
+					Login:                   login,
+					AssigningTeam:           team,
+					PlanType:                seat.PlanType,
+					CreatedAt:               seat.CreatedAt,
+					LastActivityAt:          seat.LastActivityAt,
+					LastActivityEditor:      seat.LastActivityEditor,
+					PendingCancellationDate: seat.PendingCancellationDate,
+				})
+			}
+		}
+		sort.Slice(seats, func(i, j int) bool {
+			if seats[i].Org != seats[j].Org {
+				return seats[i].Org < seats[j].Org
+			}
+			return seats[i].Login < seats[j].Login
+		})
+
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			cw := csv.NewWriter(w)
+			cw.Write(apiSeatsCSVHeader)
+			for _, seat := range seats {
+				cw.Write(seat.csvRecord())
+			}
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				log.Printf("Error encoding seats CSV: %v", err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(apiSeatsResponse{Seats: seats}); err != nil {
+			log.Printf("Error encoding API response: %v", err)
+		}
+	})
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func apiCollector(team string, metrics CopilotAPIResponse, err error) *CopilotCollector {
+	collector := NewCopilotCollector("test-token", "test-org", team, "")
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return metrics, err
+	}
+	return collector
+}
+
+func queryAPI(t *testing.T, handler http.Handler, query string) apiMetricsResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", apiMetricsEndpoint+"?"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", ct)
+	}
+
+	var response apiMetricsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+	return response
+}
+
+func apiTestMetrics(t *testing.T) CopilotAPIResponse {
+	t.Helper()
+	var metrics CopilotAPIResponse
+	err := json.Unmarshal([]byte(`[
+		{
+			"day": "2024-01-01",
+			"total_suggestions_count": 100,
+			"total_acceptances_count": 25,
+			"total_lines_suggested": 200,
+			"total_lines_accepted": 100
+		},
+		{
+			"day": "2024-01-02",
+			"total_suggestions_count": 10,
+			"total_acceptances_count": 5,
+			"breakdown": [
+				{"language": "go", "editor": "vscode", "suggestions_count": 6},
+				{"language": "python", "editor": "jetbrains", "suggestions_count": 4},
+				{"model": "default", "chat_turns": 3}
+			],
+			"copilot_ide_code_completions": {
+				"languages": [{"name": "go"}, {"name": "python"}],
+				"editors": [
+					{
+						"name": "vscode",
+						"models": [{
+							"name": "default",
+							"languages": [
+								{"name": "go", "total_code_suggestions": 8, "total_code_acceptances": 2, "total_code_lines_suggested": 10, "total_code_lines_accepted": 5},
+								{"name": "python", "total_code_suggestions": 4, "total_code_acceptances": 1}
+							]
+						}]
+					},
+					{
+						"name": "jetbrains",
+						"models": [{"name": "default", "languages": [{"name": "python", "total_code_suggestions": 2}]}]
+					}
+				]
+			},
+			"copilot_ide_chat": {
+				"editors": [
+					{"name": "vscode", "models": [{"name": "default", "total_chats": 3}, {"name": "custom", "total_chats": 1}]},
+					{"name": "jetbrains", "models": [{"name": "default", "total_chats": 2}]}
+				]
+			},
+			"copilot_dotcom_chat": {"models": [{"name": "default", "total_chats": 5}]},
+			"copilot_dotcom_pull_requests": {
+				"repositories": [{"name": "repo", "models": [{"name": "custom", "total_pr_summaries_created": 1}]}]
+			}
+		}
+	]`), &metrics)
+	if err != nil {
+		t.Fatalf("Error decoding test metrics: %v", err)
+	}
+	return metrics
+}
+
+func TestMetricsAPIHandler(t *testing.T) {
+	handler := newMetricsAPIHandler(func() []*CopilotCollector {
+		return []*CopilotCollector{apiCollector("", apiTestMetrics(t), nil)}
+	})
+
+	response := queryAPI(t, handler, "")
+	if len(response.Targets) != 1 {
+		t.Fatalf("Expected 1 target, got %d", len(response.Targets))
+	}
+	target := response.Targets[0]
+	if target.Org != "test-org" || target.Scope != "org" || target.UpdatedAt == nil || target.Error != "" {
+		t.Errorf("Unexpected target %+v", target)
+	}
+	if len(target.Days) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(target.Days))
+	}
+
+	first := target.Days[0]
+	if first.TotalSuggestionsCount != 100 || first.Rates.AcceptanceRate != 0.25 || first.Rates.LinesAcceptanceRate != 0.5 {
+		t.Errorf("Unexpected first day %+v", first)
+	}
+
+	rates := target.Days[1].Rates.IDECodeCompletions
+	if len(rates) != 3 {
+		t.Fatalf("Expected 3 code completion rates, got %+v", rates)
+	}
+	expected := apiCodeCompletionsRates{Editor: "vscode", Model: "default", Language: "go", AcceptanceRate: 0.25, LinesAcceptanceRate: 0.5}
+	if rates[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, rates[0])
+	}
+	if rates[2].AcceptanceRate != 0 || rates[2].LinesAcceptanceRate != 0 {
+		t.Errorf("Expected zero rates without acceptances, got %+v", rates[2])
+	}
+}
+
+func TestMetricsAPIHandler_Filters(t *testing.T) {
+	handler := newMetricsAPIHandler(func() []*CopilotCollector {
+		return []*CopilotCollector{
+			apiCollector("", apiTestMetrics(t), nil),
+			apiCollector("platform", apiTestMetrics(t), nil),
+		}
+	})
+
+	t.Run("day range", func(t *testing.T) {
+		response := queryAPI(t, handler, "since=2024-01-02&until=2024-01-31")
+		for _, target := range response.Targets {
+			if len(target.Days) != 1 || target.Days[0].Day != "2024-01-02" {
+				t.Errorf("Expected only 2024-01-02 for %s, got %+v", target.Team, target.Days)
+			}
+		}
+	})
+
+	t.Run("team", func(t *testing.T) {
+		response := queryAPI(t, handler, "team=platform")
+		if len(response.Targets) != 1 || response.Targets[0].Team != "platform" || response.Targets[0].Scope != "team" {
+			t.Errorf("Expected only the platform team, got %+v", response.Targets)
+		}
+	})
+
+	t.Run("editor", func(t *testing.T) {
+		day := queryAPI(t, handler, "editor=JetBrains&until=2024-01-02").Targets[0].Days[1]
+		if len(day.Breakdown) != 2 || day.Breakdown[0].Editor != "jetbrains" || day.Breakdown[1].Model != "default" {
+			t.Errorf("Expected the jetbrains and editor-less breakdowns, got %+v", day.Breakdown)
+		}
+		if len(day.CopilotIDECodeCompletions.Editors) != 1 || len(day.CopilotIDEChat.Editors) != 1 {
+			t.Errorf("Expected only the jetbrains editors, got %+v and %+v", day.CopilotIDECodeCompletions.Editors, day.CopilotIDEChat.Editors)
+		}
+		if len(day.CopilotIDECodeCompletions.Languages) != 2 || len(day.CopilotDotcomChat.Models) != 1 {
+			t.Error("Expected breakdowns without editors to be kept")
+		}
+		if day.TotalSuggestionsCount != 10 {
+			t.Errorf("Expected day totals to be kept, got %d", day.TotalSuggestionsCount)
+		}
+	})
+
+	t.Run("language", func(t *testing.T) {
+		day := queryAPI(t, handler, "language=go").Targets[0].Days[1]
+		if len(day.CopilotIDECodeCompletions.Editors) != 1 || day.CopilotIDECodeCompletions.Editors[0].Name != "vscode" {
+			t.Errorf("Expected jetbrains to be dropped without go completions, got %+v", day.CopilotIDECodeCompletions.Editors)
+		}
+		if len(day.Rates.IDECodeCompletions) != 1 || day.Rates.IDECodeCompletions[0].Language != "go" {
+			t.Errorf("Expected only go rates, got %+v", day.Rates.IDECodeCompletions)
+		}
+	})
+
+	t.Run("model", func(t *testing.T) {
+		day := queryAPI(t, handler, "model=custom").Targets[0].Days[1]
+		if len(day.CopilotIDECodeCompletions.Editors) != 0 {
+			t.Errorf("Expected no code completion editors, got %+v", day.CopilotIDECodeCompletions.Editors)
+		}
+		chat := day.CopilotIDEChat.Editors
+		if len(chat) != 1 || len(chat[0].Models) != 1 || chat[0].Models[0].Name != "custom" {
+			t.Errorf("Expected only the custom chat model, got %+v", chat)
+		}
+		if len(day.CopilotDotcomChat.Models) != 0 || len(day.CopilotDotcomPullRequests.Repositories) != 1 {
+			t.Errorf("Unexpected dotcom models %+v and repositories %+v", day.CopilotDotcomChat.Models, day.CopilotDotcomPullRequests.Repositories)
+		}
+	})
+}
+
+func TestMetricsAPIHandler_Stale(t *testing.T) {
+	fail := false
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithMaxStaleness(time.Hour))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		if fail {
+			return nil, errors.New("API request failed")
+		}
+		return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 10}}, nil
+	}
+	handler := newMetricsAPIHandler(func() []*CopilotCollector {
+		return []*CopilotCollector{collector}
+	})
+
+	if days := queryAPI(t, handler, "").Targets[0].Days; len(days) != 1 {
+		t.Fatalf("Expected 1 day, got %+v", days)
+	}
+
+	// Fetches start failing and the last good data ages past MAX_STALENESS, as on /metrics
+	fail = true
+	collector.mu.Lock()
+	collector.snapshotTime = time.Now().Add(-2 * time.Hour)
+	collector.mu.Unlock()
+
+	target := queryAPI(t, handler, "").Targets[0]
+	if target.Error != "API request failed" || len(target.Days) != 0 {
+		t.Errorf("Expected the fetch error without stale days, got %+v", target)
+	}
+}
+
+func TestMetricsAPIHandler_FetchError(t *testing.T) {
+	handler := newMetricsAPIHandler(func() []*CopilotCollector {
+		return []*CopilotCollector{apiCollector("", nil, errors.New("API request failed"))}
+	})
+
+	target := queryAPI(t, handler, "").Targets[0]
+	if target.Error != "API request failed" || target.UpdatedAt != nil || len(target.Days) != 0 {
+		t.Errorf("Expected the fetch error without days, got %+v", target)
+	}
+}
+
+func TestMetricsAPIHandler_BadRequest(t *testing.T) {
+	handler := newMetricsAPIHandler(func() []*CopilotCollector {
+		t.Error("Unexpected collectors call for invalid query")
+		return nil
+	})
+
+	for _, query := range []string{"since=yesterday", "until=2024-13-01", "since=2024-02-01&until=2024-01-01"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", apiMetricsEndpoint+"?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestSeatsAPIHandler(t *testing.T) {
+	server := newSeatsServer(t)
+	defer server.Close()
+
+	collectors := func() []*CopilotCollector {
+		return []*CopilotCollector{
+			NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL), WithSeats(true)),
+			NewCopilotCollector("test-token", "test-org", "platform", "", WithBaseURL(server.URL), WithSeats(true)),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		hashKey  string
+		query    string
+		expected []string
+	}{
+		{name: "all", query: "", expected: []string{"alice", "bob", "carol"}},
+		{name: "team", query: "team=platform", expected: []string{"alice"}},
+		{name: "never active", query: "idle_days=never", expected: []string{"bob", "carol"}},
+		{name: "idle", query: "idle_days=30", expected: []string{"alice", "bob", "carol"}},
+		{name: "other org", query: "org=other-org", expected: []string{}},
+		{name: "hashed", hashKey: "secret", query: "team=platform", expected: []string{hashLogin("secret", "alice")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newSeatsAPIHandler(collectors, tt.hashKey)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", apiSeatsEndpoint+"?"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+
+			var response apiSeatsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+			logins := []string{}
+			for _, seat := range response.Seats {
+				logins = append(logins, seat.Login)
+			}
+			if strings.Join(logins, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, logins)
+			}
+		})
+	}
+}
+
+func TestSeatsAPIHandler_Enterprise(t *testing.T) {
+	server := newEnterpriseSeatsServer(t)
+	defer server.Close()
+
+	handler := newSeatsAPIHandler(func() []*CopilotCollector {
+		return []*CopilotCollector{NewCopilotCollector("test-token", "", "", "test-enterprise", WithBaseURL(server.URL), WithSeats(true))}
+	}, "")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", apiSeatsEndpoint+"?org=acme-web", nil))
+
+	var response apiSeatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+	if len(response.Seats) != 2 {
+		t.Fatalf("Expected the 2 acme-web seats, got %+v", response.Seats)
+	}
+	for _, seat := range response.Seats {
+		if seat.Org != "acme-web" || seat.Enterprise != "test-enterprise" {
+			t.Errorf("Expected org acme-web in test-enterprise, got %+v", seat)
+		}
+	}
+}
+
+func TestSeatsAPIHandler_Stale(t *testing.T) {
+	server := newSeatsServer(t)
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "",
+		WithBaseURL(server.URL), WithSeats(true), WithPollInterval(time.Hour), WithMaxStaleness(time.Hour))
+	if err := collector.refreshSeats(); err != nil {
+		t.Fatalf("Error fetching seats: %v", err)
+	}
+	handler := newSeatsAPIHandler(func() []*CopilotCollector {
+		return []*CopilotCollector{collector}
+	}, "")
+
+	query := func() []apiSeat {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", apiSeatsEndpoint, nil))
+		var response apiSeatsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Error decoding response: %v", err)
+		}
+		return response.Seats
+	}
+
+	if seats := query(); len(seats) != 3 {
+		t.Fatalf("Expected 3 seats, got %+v", seats)
+	}
+
+	// The last seat snapshot ages past MAX_STALENESS, as on /metrics
+	collector.mu.Lock()
+	collector.seatsTime = time.Now().Add(-2 * time.Hour)
+	collector.mu.Unlock()
+
+	if seats := query(); len(seats) != 0 {
+		t.Errorf("Expected no stale seats, got %+v", seats)
+	}
+}
+
+func TestSeatsAPIHandler_CSV(t *testing.T) {
+	server := newSeatsServer(t)
+	defer server.Close()
+
+	handler := newSeatsAPIHandler(func() []*CopilotCollector {
+		return []*CopilotCollector{NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL), WithSeats(true))}
+	}, "")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", apiSeatsEndpoint+"?format=csv&team=platform", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Expected Content-Type text/csv, got %s", ct)
+	}
+
+	expected := `org,login,assigning_team,plan_type,created_at,last_activity_at,last_activity_editor,pending_cancellation_date,enterprise
+test-org,alice,platform,business,2024-01-01T00:00:00Z,2024-02-01T10:00:00Z,vscode/1.85.0/copilot/1.150.0,,
+`
+	if rec.Body.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, rec.Body.String())
+	}
+}
+
+func TestSeatsAPIHandler_BadRequest(t *testing.T) {
+	handler := newSeatsAPIHandler(func() []*CopilotCollector { return nil }, "")
+
+	for _, query := range []string{"format=xml", "idle_days=-1", "idle_days=soon"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", apiSeatsEndpoint+"?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestHashLogin(t *testing.T) {
+	hashed := hashLogin("secret", "Octocat")
+	if hashed == "Octocat" || len(hashed) != 64 {
+		t.Errorf("Expected a hex SHA-256 HMAC, got %q", hashed)
+	}
+	if hashLogin("secret", "octocat") != hashed {
+		t.Error("Expected logins to be hashed case-insensitively")
+	}
+	if hashLogin("other", "octocat") == hashed {
+		t.Error("Expected the hash to depend on the key")
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// GitHub rejects App JWTs valid for more than 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// Backdate JWTs to tolerate clock drift between the exporter and GitHub
+	appJWTClockSkew = 60 * time.Second
+	// Installation tokens last an hour; refresh them well before they expire
+	installationTokenRefreshMargin = 5 * time.Minute
+)
+
+// tokenSource supplies the bearer token for GitHub API requests
+type tokenSource interface {
+	Token() (string, error)
+}
+
+// appTokenSource mints GitHub App JWTs and exchanges them for installation access tokens,
+// caching each token until shortly before it expires
+type appTokenSource struct {
+	appID          string
+	installationID string
+	key            *rsa.PrivateKey
+	baseURL        string
+	httpClient     *http.Client
+	now            func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newAppTokenSource(baseURL, appID, installationID string, keyPEM []byte) (*appTokenSource, error) {
+	key, err := parseRSAPrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		baseURL:        baseURL,
+		httpClient:     &http.Client{Timeout: defaultHTTPTimeout},
+		now:            time.Now,
+	}, nil
+}
+
+// Token returns a valid installation access token, requesting a new one when needed
+func (s *appTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Add(installationTokenRefreshMargin).Before(s.expires) {
+		return s.token, nil
+	}
+
+	token, expires, err := s.requestInstallationToken()
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.expires = expires
+
+	return s.token, nil
+}
+
+// requestInstallationToken exchanges a freshly minted App JWT for an installation access token
+func (s *appTokenSource) requestInstallationToken() (string, time.Time, error) {
+	jwt, err := s.jwt()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	apiURL := fmt.Sprintf("%s/app/installations/%s/access_tokens", s.baseURL, s.installationID)
+	req, err := http.NewRequest("POST", apiURL, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error creating installation token request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error requesting installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error reading installation token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", time.Time{}, fmt.Errorf("installation token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", time.Time{}, fmt.Errorf("error unmarshaling installation token response: %w", err)
+	}
+	if result.Token == "" {
+		return "", time.Time{}, errors.New("installation token response did not contain a token")
+	}
+
+	return result.Token, result.ExpiresAt, nil
+}
+
+// jwt returns an RS256-signed JSON Web Token identifying the GitHub App
+func (s *appTokenSource) jwt() (string, error) {
+	now := s.now()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing app JWT: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey decodes a PEM encoded PKCS #1 or PKCS #8 RSA private key
+func parseRSAPrivateKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+
+	return key, nil
+}
+
+// validateAppID checks that an App or installation ID is numeric
+func validateAppID(name, value string) error {
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return fmt.Errorf("%s must be numeric, got %q", name, value)
+	}
+	return nil
+}
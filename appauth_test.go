@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper to create a PEM encoded PKCS #1 key like the ones GitHub issues for Apps
+func generateTestKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return key, keyPEM
+}
+
+func TestAppTokenSource_JWT(t *testing.T) {
+	key, keyPEM := generateTestKey(t)
+
+	source, err := newAppTokenSource(defaultGitHubAPIURL, "12345", "678", keyPEM)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	source.now = func() time.Time { return now }
+
+	jwt, err := source.jwt()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 JWT segments, got %d", len(parts))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("Failed to decode signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Signature does not verify: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("Failed to decode claims: %v", err)
+	}
+	var claims struct {
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("Failed to unmarshal claims: %v", err)
+	}
+	if claims.Iss != "12345" {
+		t.Errorf("Expected issuer '12345', got '%s'", claims.Iss)
+	}
+	if claims.Iat != now.Unix()-60 || claims.Exp != now.Add(9*time.Minute).Unix() {
+		t.Errorf("Unexpected validity window iat=%d exp=%d", claims.Iat, claims.Exp)
+	}
+}
+
+func TestAppTokenSource_Token(t *testing.T) {
+	_, keyPEM := generateTestKey(t)
+
+	now := time.Unix(1700000000, 0)
+	exchanges := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges++
+		if r.Method != "POST" || r.URL.Path != "/app/installations/678/access_tokens" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if strings.Count(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".") != 2 {
+			t.Errorf("Expected JWT bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, exchanges, now.Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	source, err := newAppTokenSource(server.URL, "12345", "678", keyPEM)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	source.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		token, err := source.Token()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if token != "ghs_1" {
+			t.Errorf("Expected cached token 'ghs_1', got '%s'", token)
+		}
+	}
+
+	// Within the refresh margin of expiry a new token is requested
+	now = now.Add(56 * time.Minute)
+	token, err := source.Token()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if token != "ghs_2" || exchanges != 2 {
+		t.Errorf("Expected refreshed token 'ghs_2' after 2 exchanges, got '%s' after %d", token, exchanges)
+	}
+}
+
+func TestAppTokenSource_TokenError(t *testing.T) {
+	_, keyPEM := generateTestKey(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message": "A JSON web token could not be decoded"}`)
+	}))
+	defer server.Close()
+
+	source, err := newAppTokenSource(server.URL, "12345", "678", keyPEM)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := source.Token(); err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Errorf("Expected status 401 error, got %v", err)
+	}
+}
+
+func TestParseRSAPrivateKey(t *testing.T) {
+	key, pkcs1 := generateTestKey(t)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal PKCS #8 key: %v", err)
+	}
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	for name, keyPEM := range map[string][]byte{"pkcs1": pkcs1, "pkcs8": pkcs8} {
+		parsed, err := parseRSAPrivateKey(keyPEM)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if !parsed.Equal(key) {
+			t.Errorf("%s: parsed key does not match", name)
+		}
+	}
+
+	if _, err := parseRSAPrivateKey([]byte("not a key")); err == nil {
+		t.Error("Expected error for non-PEM input")
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_TokenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghs_installation" {
+			t.Errorf("Expected installation token, got %q", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("", "test-org", "", "", WithTokenSource(staticTokenSource("ghs_installation")), WithBaseURL(server.URL))
+
+	if _, err := collector.fetchMetrics(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// staticTokenSource is a fixed token for tests
+type staticTokenSource string
+
+func (s staticTokenSource) Token() (string, error) {
+	return string(s), nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	defaultRemoteWriteBatchSize = 1000
+	remoteWriteTimeout          = time.Minute
+)
+
+// runBackfill implements the backfill subcommand: it fetches the history of every configured target and
+// pushes it to a remote-write endpoint or writes it as OpenMetrics for promtool tsdb create-blocks-from openmetrics
+func runBackfill(args []string) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	remoteWriteURL := fs.String("remote-write-url", "", "Prometheus remote-write endpoint to push the history to")
+	output := fs.String("output", "", "OpenMetrics file to write the history to, for promtool tsdb create-blocks-from openmetrics")
+	days := fs.Int("days", maxLookbackDays, "Days of history to fetch, ending today (ignored if METRICS_SINCE is set)")
+	batchSize := fs.Int("batch-size", defaultRemoteWriteBatchSize, "Series per remote-write request")
+	fs.Parse(args)
+
+	if (*remoteWriteURL == "") == (*output == "") {
+		log.Fatal("backfill needs exactly one of -remote-write-url or -output")
+	}
+	if *days < 1 || *days > maxLookbackDays {
+		log.Fatalf("-days must be a number between 1 and %d", maxLookbackDays)
+	}
+	if *batchSize < 1 {
+		log.Fatal("-batch-size must be positive")
+	}
+
+	cfg := configFromEnv()
+	if len(cfg.targets) == 0 {
+		log.Fatal("Either GITHUB_ORG, GITHUB_ENTERPRISE or TARGETS_FILE is required for backfill")
+	}
+	if cfg.store != nil {
+		defer cfg.store.Close()
+	}
+
+	families, err := gatherHistory(cfg, *days)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Error creating %s: %v", *output, err)
+		}
+		if err := writeOpenMetrics(f, families); err != nil {
+			f.Close()
+			log.Fatalf("Error writing %s: %v", *output, err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("Error writing %s: %v", *output, err)
+		}
+		log.Printf("Wrote %d metric families to %s", len(families), *output)
+		return
+	}
+
+	client := &http.Client{Timeout: remoteWriteTimeout}
+	series := timeSeriesFromFamilies(families)
+	if err := remoteWrite(client, *remoteWriteURL, os.Getenv("REMOTE_WRITE_BEARER_TOKEN"), series, *batchSize); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Pushed %d series to %s", len(series), *remoteWriteURL)
+}
+
+// gatherHistory fetches every target, including discovered teams, and returns its daily samples, with the
+// stored history when a store is configured, timestamped at each day's midnight UTC. Metrics without a day,
+// such as scrape_success and seats, are left out and seats are not fetched.
+func gatherHistory(cfg *config, days int) ([]*dto.MetricFamily, error) {
+	reg := prometheus.NewRegistry()
+	opts := []CollectorOption{WithPollInterval(0), WithDayMode(DayModeTimestamp), WithLookbackDays(days), WithSeats(false), WithStoredHistory(true)}
+
+	labels := targetLabels(cfg.targets)
+	for i, target := range cfg.targets {
+		registerer := prometheus.WrapRegistererWith(labels[i], reg)
+		collector := cfg.newCollector(target, cfg.targetTokens[i], opts...)
+		if err := registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("error registering target %s: %w", target, err)
+		}
+
+		if !target.DiscoverTeams {
+			continue
+		}
+		slugs, err := collector.fetchTeams()
+		if err != nil {
+			return nil, fmt.Errorf("error discovering teams for %s: %w", target, err)
+		}
+		filter, _ := newTeamFilter(target.TeamInclude, target.TeamExclude)
+		for _, slug := range slugs {
+			if !filter.match(slug) {
+				continue
+			}
+			team := Target{Organization: target.Organization, Team: slug}
+			if err := registerer.Register(cfg.newCollector(team, cfg.targetTokens[i], opts...)); err != nil {
+				log.Printf("Error registering discovered team %s: %v", team, err)
+			}
+		}
+	}
+
+	gathered, err := reg.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("error gathering metrics: %w", err)
+	}
+
+	var families []*dto.MetricFamily
+	var failed []string
+	for _, mf := range gathered {
+		if mf.GetName() == "github_copilot_scrape_success" {
+			for _, m := range mf.GetMetric() {
+				if m.GetGauge().GetValue() == 0 {
+					failed = append(failed, labelString(m.GetLabel()))
+				}
+			}
+		}
+
+		var daily []*dto.Metric
+		for _, m := range mf.GetMetric() {
+			if m.TimestampMs != nil {
+				daily = append(daily, m)
+			}
+		}
+		if len(daily) > 0 {
+			mf.Metric = daily
+			families = append(families, mf)
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("fetching metrics failed for %s", strings.Join(failed, ", "))
+	}
+
+	return families, nil
+}
+
+// labelString formats label pairs as name="value" for error messages
+func labelString(pairs []*dto.LabelPair) string {
+	parts := make([]string, len(pairs))
+	for i, pair := range pairs {
+		parts[i] = fmt.Sprintf("%s=%q", pair.GetName(), pair.GetValue())
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// writeOpenMetrics encodes families in the OpenMetrics text format, terminated by # EOF
+func writeOpenMetrics(w io.Writer, families []*dto.MetricFamily) error {
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeOpenMetrics))
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+	if closer, ok := enc.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// remoteSample is a sample of a remote-write time series
+type remoteSample struct {
+	value     float64
+	timestamp int64
+}
+
+// remoteSeries is a remote-write time series: its labels, including __name__, sorted by name, and its samples in time order
+type remoteSeries struct {
+	labels  []*dto.LabelPair
+	samples []remoteSample
+}
+
+// timeSeriesFromFamilies groups the gauge samples of families into one series per label set
+func timeSeriesFromFamilies(families []*dto.MetricFamily) []remoteSeries {
+	var series []remoteSeries
+	index := make(map[string]int)
+
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			labels := []*dto.LabelPair{{Name: stringPtr("__name__"), Value: stringPtr(name)}}
+			labels = append(labels, m.GetLabel()...)
+			sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+
+			key := labelString(labels)
+			i, ok := index[key]
+			if !ok {
+				i = len(series)
+				index[key] = i
+				series = append(series, remoteSeries{labels: labels})
+			}
+			series[i].samples = append(series[i].samples, remoteSample{value: m.GetGauge().GetValue(), timestamp: m.GetTimestampMs()})
+		}
+	}
+
+	for _, s := range series {
+		sort.Slice(s.samples, func(i, j int) bool { return s.samples[i].timestamp < s.samples[j].timestamp })
+	}
+	return series
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// encodeWriteRequest encodes series as a remote-write 1.0 prometheus.WriteRequest protobuf message
+func encodeWriteRequest(series []remoteSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, label := range s.labels {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, label.GetName())
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, label.GetValue())
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, l)
+		}
+		for _, sample := range s.samples {
+			var smp []byte
+			smp = protowire.AppendTag(smp, 1, protowire.Fixed64Type)
+			smp = protowire.AppendFixed64(smp, math.Float64bits(sample.value))
+			smp = protowire.AppendTag(smp, 2, protowire.VarintType)
+			smp = protowire.AppendVarint(smp, uint64(sample.timestamp))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, smp)
+		}
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+// remoteWrite pushes series to a remote-write endpoint in batches of batchSize series
+func remoteWrite(client *http.Client, url, bearerToken string, series []remoteSeries, batchSize int) error {
+	for start := 0; start < len(series); start += batchSize {
+		end := min(start+batchSize, len(series))
+
+		body := snappy.Encode(nil, encodeWriteRequest(series[start:end]))
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("error creating remote-write request: %w", err)
+		}
+		req.Header.Set("Content-Encoding", "snappy")
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+		req.Header.Set("User-Agent", "github-copilot-metrics-exporter")
+		if bearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+bearerToken)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error sending remote-write request: %w", err)
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("remote write failed with status %d: %s", resp.StatusCode, string(respBody))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func newHistoryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/test-org/copilot/metrics":
+			if r.URL.Query().Get("since") == "" {
+				t.Errorf("Expected since parameter for backfill")
+			}
+			fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 10}, {"day": "2024-01-02", "total_active_users": 12}]`)
+		case "/orgs/test-org/teams":
+			fmt.Fprint(w, `[{"slug": "platform"}, {"slug": "bots"}]`)
+		case "/orgs/test-org/team/platform/copilot/metrics":
+			fmt.Fprint(w, `[{"day": "2024-01-02", "total_active_users": 4}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGatherHistory(t *testing.T) {
+	server := newHistoryServer(t)
+	defer server.Close()
+
+	cfg := &config{
+		targets:      []Target{{Organization: "test-org", DiscoverTeams: true, TeamExclude: "^bots$"}},
+		targetTokens: []string{""},
+		githubToken:  "test-token",
+		opts:         []CollectorOption{WithBaseURL(server.URL)},
+	}
+
+	families, err := gatherHistory(cfg, 30)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeOpenMetrics(&out, families); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Samples carry each day's midnight UTC as timestamp, in seconds in OpenMetrics
+	for _, expected := range []string{
+		`github_copilot_active_users_total{org="test-org",scope="org",team=""} 10.0 1.7040672e+09`,
+		`github_copilot_active_users_total{org="test-org",scope="org",team=""} 12.0 1.7041536e+09`,
+		`github_copilot_active_users_total{org="test-org",scope="team",team="platform"} 4.0 1.7041536e+09`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %s in output:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "scrape_success") || strings.Contains(out.String(), `team="bots"`) {
+		t.Errorf("Unexpected exporter metrics or excluded team in output:\n%s", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "# EOF\n") {
+		t.Errorf("Expected output to end with # EOF")
+	}
+}
+
+func TestGatherHistory_FetchError(t *testing.T) {
+	server := newHistoryServer(t)
+	defer server.Close()
+
+	cfg := &config{
+		targets:      []Target{{Organization: "test-org"}, {Organization: "unknown-org"}},
+		targetTokens: []string{"", ""},
+		githubToken:  "test-token",
+		opts:         []CollectorOption{WithBaseURL(server.URL)},
+	}
+
+	_, err := gatherHistory(cfg, 30)
+	if err == nil || !strings.Contains(err.Error(), `org="unknown-org"`) {
+		t.Errorf("Expected error naming unknown-org, got %v", err)
+	}
+}
+
+// decodedSeries is a remote-write time series decoded in tests
+type decodedSeries struct {
+	labels  map[string]string
+	samples []remoteSample
+}
+
+// decodeWriteRequest decodes the fields of a WriteRequest that encodeWriteRequest produces
+func decodeWriteRequest(t *testing.T, data []byte) []decodedSeries {
+	t.Helper()
+
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64)) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				t.Fatalf("Invalid tag: %v", protowire.ParseError(n))
+			}
+			b = b[n:]
+			switch typ {
+			case protowire.BytesType:
+				v, m := protowire.ConsumeBytes(b)
+				fn(num, typ, v, 0)
+				b = b[m:]
+			case protowire.Fixed64Type:
+				v, m := protowire.ConsumeFixed64(b)
+				fn(num, typ, nil, v)
+				b = b[m:]
+			case protowire.VarintType:
+				v, m := protowire.ConsumeVarint(b)
+				fn(num, typ, nil, v)
+				b = b[m:]
+			default:
+				t.Fatalf("Unexpected wire type %v", typ)
+			}
+		}
+	}
+
+	var series []decodedSeries
+	fields(data, func(_ protowire.Number, _ protowire.Type, ts []byte, _ uint64) {
+		s := decodedSeries{labels: map[string]string{}}
+		fields(ts, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+			switch num {
+			case 1:
+				var name, value string
+				fields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+					if num == 1 {
+						name = string(v)
+					} else {
+						value = string(v)
+					}
+				})
+				s.labels[name] = value
+			case 2:
+				var sample remoteSample
+				fields(v, func(num protowire.Number, _ protowire.Type, _ []byte, n uint64) {
+					if num == 1 {
+						sample.value = math.Float64frombits(n)
+					} else {
+						sample.timestamp = int64(n)
+					}
+				})
+				s.samples = append(s.samples, sample)
+			}
+		})
+		series = append(series, s)
+	})
+	return series
+}
+
+func TestRemoteWrite(t *testing.T) {
+	server := newHistoryServer(t)
+	defer server.Close()
+
+	cfg := &config{
+		targets:      []Target{{Organization: "test-org", Labels: map[string]string{"env": "prod"}}},
+		targetTokens: []string{""},
+		githubToken:  "test-token",
+		opts:         []CollectorOption{WithBaseURL(server.URL)},
+	}
+	families, err := gatherHistory(cfg, 30)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	series := timeSeriesFromFamilies(families)
+
+	var mu sync.Mutex
+	var requests int
+	var received []decodedSeries
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("Unexpected content headers %v", r.Header)
+		}
+		if r.Header.Get("Authorization") != "Bearer write-token" {
+			t.Errorf("Expected Authorization header 'Bearer write-token'")
+		}
+
+		compressed, _ := io.ReadAll(r.Body)
+		data, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			t.Errorf("Invalid snappy body: %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		received = append(received, decodeWriteRequest(t, data)...)
+	}))
+	defer receiver.Close()
+
+	if err := remoteWrite(http.DefaultClient, receiver.URL, "write-token", series, 5); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(received) != len(series) {
+		t.Fatalf("Expected %d series, got %d", len(series), len(received))
+	}
+	if expected := (len(series) + 4) / 5; requests != expected {
+		t.Errorf("Expected %d requests, got %d", expected, requests)
+	}
+
+	found := false
+	for _, s := range received {
+		if s.labels["__name__"] != "github_copilot_active_users_total" {
+			continue
+		}
+		found = true
+		if s.labels["org"] != "test-org" || s.labels["env"] != "prod" || s.labels["scope"] != "org" {
+			t.Errorf("Unexpected labels %v", s.labels)
+		}
+		expected := []remoteSample{{value: 10, timestamp: 1704067200000}, {value: 12, timestamp: 1704153600000}}
+		if fmt.Sprint(s.samples) != fmt.Sprint(expected) {
+			t.Errorf("Expected samples %v, got %v", expected, s.samples)
+		}
+	}
+	if !found {
+		t.Error("Expected github_copilot_active_users_total series")
+	}
+}
+
+func TestRemoteWrite_Error(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of bounds", http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	series := []remoteSeries{{labels: nil, samples: []remoteSample{{value: 1, timestamp: 1}}}}
+	err := remoteWrite(http.DefaultClient, receiver.URL, "", series, 10)
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("Expected status 400 error, got %v", err)
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cachedPage is a decoded metrics page together with the validators needed to revalidate it
+type cachedPage struct {
+	etag         string
+	lastModified string
+	metrics      CopilotAPIResponse
+	next         string
+}
+
+// fetchMetrics retrieves the configured metrics window, following Link pagination until the last page
+func (c *CopilotCollector) fetchMetrics() (CopilotAPIResponse, error) {
+	apiURL := c.metricsURL()
+
+	c.mu.RLock()
+	previous := c.pageCache
+	c.mu.RUnlock()
+
+	// Only pages from this fetch are kept, so the cache cannot grow as the query window moves
+	pages := make(map[string]cachedPage)
+
+	var metrics CopilotAPIResponse
+	for apiURL != "" {
+		page, err := c.fetchMetricsPage(apiURL, previous[apiURL])
+		if err != nil {
+			return nil, err
+		}
+		pages[apiURL] = page
+		metrics = append(metrics, page.metrics...)
+		apiURL = page.next
+	}
+
+	c.mu.Lock()
+	c.pageCache = pages
+	c.mu.Unlock()
+
+	return metrics, nil
+}
+
+// metricsURL builds the first-page URL for the configured scope and query window
+func (c *CopilotCollector) metricsURL() string {
+	var apiURL string
+
+	if c.enterprise != "" {
+		apiURL = fmt.Sprintf("%s/enterprises/%s/copilot/metrics", c.baseURL, url.PathEscape(c.enterprise))
+	} else if c.team != "" {
+		apiURL = fmt.Sprintf("%s/orgs/%s/team/%s/copilot/metrics", c.baseURL, url.PathEscape(c.organization), url.PathEscape(c.team))
+	} else {
+		apiURL = fmt.Sprintf("%s/orgs/%s/copilot/metrics", c.baseURL, url.PathEscape(c.organization))
+	}
+
+	query := url.Values{}
+
+	since := c.since
+	if since.IsZero() && c.lookbackDays > 0 {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		since = today.AddDate(0, 0, -(c.lookbackDays - 1))
+	}
+	if !since.IsZero() {
+		query.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	if !c.until.IsZero() {
+		query.Set("until", c.until.UTC().Format(time.RFC3339))
+	}
+	if c.perPage > 0 {
+		query.Set("per_page", strconv.Itoa(c.perPage))
+	}
+
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+
+	return apiURL
+}
+
+// fetchMetricsPage retrieves a single page, revalidating the previously cached copy if there is one.
+// Not Modified responses reuse the cached page and do not count against the rate limit.
+func (c *CopilotCollector) fetchMetricsPage(apiURL string, cached cachedPage) (cachedPage, error) {
+	req, err := c.newAPIRequest(apiURL)
+	if err != nil {
+		return cachedPage{}, err
+	}
+	if cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+	if cached.lastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.lastModified)
+	}
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return cachedPage{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && (cached.etag != "" || cached.lastModified != "") {
+		return cached, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return cachedPage{}, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cachedPage{}, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	var metrics CopilotAPIResponse
+	if err := json.Unmarshal(body, &metrics); err != nil {
+		return cachedPage{}, fmt.Errorf("error unmarshaling response: %w", err)
+	}
+
+	return cachedPage{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		metrics:      metrics,
+		next:         nextPageURL(resp.Header.Get("Link")),
+	}, nil
+}
+
+// newAPIRequest creates an authenticated GET request for a GitHub REST API URL
+func (c *CopilotCollector) newAPIRequest(apiURL string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	token := c.githubToken
+	if c.tokenSource != nil {
+		token, err = c.tokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error obtaining GitHub token: %w", err)
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	return req, nil
+}
+
+// getJSON fetches a GitHub REST API URL and decodes its JSON body into v, returning the rel="next" page URL
+func (c *CopilotCollector) getJSON(apiURL string, v any) (string, error) {
+	req, err := c.newAPIRequest(apiURL)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return "", fmt.Errorf("error unmarshaling response: %w", err)
+	}
+
+	return nextPageURL(resp.Header.Get("Link")), nil
+}
+
+// nextPageURL extracts the rel="next" target from a GitHub Link header
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(target, "<>")
+			}
+		}
+	}
+
+	return ""
+}
+
+// rateLimit is the most recently reported GitHub API rate limit budget
+type rateLimit struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// doWithRetry sends req, retrying network errors and 5xx responses with jittered exponential backoff
+// and waiting out rate limits when GitHub asks for a wait no longer than maxRateLimitWait
+func (c *CopilotCollector) doWithRetry(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if attempt >= c.maxRetries {
+				return nil, fmt.Errorf("error making request: %w", err)
+			}
+			c.sleep(c.backoff(attempt))
+			continue
+		}
+
+		c.recordRateLimit(resp.Header)
+
+		var wait time.Duration
+		switch {
+		case isRateLimited(resp):
+			wait = rateLimitWait(resp.Header, time.Now())
+			if wait > c.maxRateLimitWait || attempt >= c.maxRetries {
+				return resp, nil
+			}
+		case resp.StatusCode >= http.StatusInternalServerError:
+			if attempt >= c.maxRetries {
+				return resp, nil
+			}
+			wait = retryAfter(resp.Header, time.Now())
+			if wait == 0 {
+				wait = c.backoff(attempt)
+			}
+		default:
+			return resp, nil
+		}
+
+		// Drain the body so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		c.sleep(wait)
+	}
+}
+
+// backoff returns a jittered exponential delay for the given zero-based attempt
+func (c *CopilotCollector) backoff(attempt int) time.Duration {
+	delay := c.retryBaseDelay << attempt
+	if delay <= 0 || delay > c.retryMaxDelay {
+		delay = c.retryMaxDelay
+	}
+	// Equal jitter: half the delay is fixed, the other half random
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// recordRateLimit stores the rate limit budget reported in response headers, if present
+func (c *CopilotCollector) recordRateLimit(header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	rl := rateLimit{limit: limit, remaining: remaining}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.reset = time.Unix(reset, 0)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateLimit = rl
+}
+
+// currentRateLimit returns the last reported rate limit budget and whether one has been seen
+func (c *CopilotCollector) currentRateLimit() (rateLimit, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rateLimit, c.rateLimit.limit > 0
+}
+
+// isRateLimited reports whether GitHub rejected the request because of a primary or secondary rate limit
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	// A plain 403 is a permissions problem; rate limits carry an exhausted budget or Retry-After
+	return resp.StatusCode == http.StatusForbidden &&
+		(resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != "")
+}
+
+// rateLimitWait returns how long GitHub asks clients to wait before retrying a rate-limited request
+func rateLimitWait(header http.Header, now time.Time) time.Duration {
+	if wait := retryAfter(header, now); wait > 0 {
+		return wait
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// Allow a second of clock skew past the reset time
+			if wait := time.Unix(reset, 0).Sub(now) + time.Second; wait > 0 {
+				return wait
+			}
+		}
+	}
+	// GitHub recommends waiting at least a minute when no other hint is given
+	return time.Minute
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// normalizeBaseURL validates a GitHub API base URL such as https://github.example.com/api/v3
+// and strips any trailing slash so endpoint paths can be appended directly
+func normalizeBaseURL(baseURL string) (string, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub API URL %q: %w", baseURL, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("invalid GitHub API URL %q: must be an absolute http or https URL", baseURL)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("invalid GitHub API URL %q: must not contain a query or fragment", baseURL)
+	}
+
+	return strings.TrimRight(baseURL, "/"), nil
+}
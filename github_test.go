@@ -0,0 +1,552 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCopilotCollector_MetricsURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		org      string
+		team     string
+		ent      string
+		opts     []CollectorOption
+		expected string
+	}{
+		{
+			name:     "organization",
+			org:      "test-org",
+			expected: "https://api.github.com/orgs/test-org/copilot/metrics?per_page=100",
+		},
+		{
+			name:     "team",
+			org:      "test-org",
+			team:     "test-team",
+			expected: "https://api.github.com/orgs/test-org/team/test-team/copilot/metrics?per_page=100",
+		},
+		{
+			name:     "enterprise",
+			ent:      "test-enterprise",
+			expected: "https://api.github.com/enterprises/test-enterprise/copilot/metrics?per_page=100",
+		},
+		{
+			name: "time range and page size",
+			org:  "test-org",
+			opts: []CollectorOption{
+				WithTimeRange(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)),
+				WithPerPage(28),
+			},
+			expected: "https://api.github.com/orgs/test-org/copilot/metrics?per_page=28&since=2024-01-01T00%3A00%3A00Z&until=2024-02-01T00%3A00%3A00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewCopilotCollector("test-token", tt.org, tt.team, tt.ent, tt.opts...)
+			if got := collector.metricsURL(); got != tt.expected {
+				t.Errorf("Expected URL %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCopilotCollector_MetricsURL_Lookback(t *testing.T) {
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithLookbackDays(100))
+
+	parsed, err := url.Parse(collector.metricsURL())
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+
+	since, err := time.Parse(time.RFC3339, parsed.Query().Get("since"))
+	if err != nil {
+		t.Fatalf("Expected RFC 3339 since parameter, got %q", parsed.Query().Get("since"))
+	}
+
+	expected := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -99)
+	if !since.Equal(expected) {
+		t.Errorf("Expected since %s, got %s", expected, since)
+	}
+}
+
+func TestTimeRangeFromEnv(t *testing.T) {
+	t.Setenv("METRICS_SINCE", "2024-01-01")
+	t.Setenv("METRICS_UNTIL", "2024-02-01T00:00:00Z")
+	since, until, err := timeRangeFromEnv()
+	if err != nil || !since.Before(until) {
+		t.Errorf("Expected a valid range, got %s to %s (%v)", since, until, err)
+	}
+
+	t.Setenv("METRICS_SINCE", "2024-03-01")
+	if _, _, err := timeRangeFromEnv(); err == nil || !strings.Contains(err.Error(), "must not be after") {
+		t.Errorf("Expected error for since after until, got %v", err)
+	}
+
+	t.Setenv("METRICS_UNTIL", "")
+	if _, _, err := timeRangeFromEnv(); err != nil {
+		t.Errorf("Expected an open-ended range to be valid, got %v", err)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			link:     "",
+			expected: "",
+		},
+		{
+			name:     "next and last",
+			link:     `<https://api.github.com/orgs/o/copilot/metrics?page=2>; rel="next", <https://api.github.com/orgs/o/copilot/metrics?page=4>; rel="last"`,
+			expected: "https://api.github.com/orgs/o/copilot/metrics?page=2",
+		},
+		{
+			name:     "last page",
+			link:     `<https://api.github.com/orgs/o/copilot/metrics?page=1>; rel="first", <https://api.github.com/orgs/o/copilot/metrics?page=3>; rel="prev"`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextPageURL(tt.link); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_Pagination(t *testing.T) {
+	var requests []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+
+		if r.URL.Path != "/orgs/test-org/copilot/metrics" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Expected Authorization header 'Bearer test-token'")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/test-org/copilot/metrics?per_page=1&page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 1}]`)
+		case "2":
+			fmt.Fprint(w, `[{"day": "2024-01-02", "total_active_users": 2}]`)
+		default:
+			t.Errorf("Unexpected page %s", r.URL.Query().Get("page"))
+		}
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithPerPage(1), WithBaseURL(server.URL))
+
+	metrics, err := collector.fetchMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(metrics) != 2 {
+		t.Fatalf("Expected 2 days across pages, got %d", len(metrics))
+	}
+	if metrics[0].Day != "2024-01-01" || metrics[1].Day != "2024-01-02" {
+		t.Errorf("Unexpected days %s, %s", metrics[0].Day, metrics[1].Day)
+	}
+	if len(requests) != 2 || requests[1] != "/orgs/test-org/copilot/metrics?per_page=1&page=2" {
+		t.Errorf("Unexpected requests %v", requests)
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	_, err := collector.fetchMetrics()
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Expected status 404 error, got %v", err)
+	}
+}
+
+// Helper to record retry waits instead of sleeping
+func recordSleeps(collector *CopilotCollector) *[]time.Duration {
+	var sleeps []time.Duration
+	collector.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+	return &sleeps
+}
+
+func TestCopilotCollector_FetchMetrics_RetriesServerErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"day": "2024-01-01"}]`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithRetries(3), WithBaseURL(server.URL))
+	sleeps := recordSleeps(collector)
+
+	metrics, err := collector.fetchMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Errorf("Expected 1 day, got %d", len(metrics))
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+	// Equal jitter keeps each wait between half and all of the exponential delay
+	if len(*sleeps) != 2 {
+		t.Fatalf("Expected 2 backoff waits, got %d", len(*sleeps))
+	}
+	for i, d := range *sleeps {
+		delay := time.Second << i
+		if d < delay/2 || d > delay {
+			t.Errorf("Backoff %d out of range: %s", i, d)
+		}
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_GivesUpAfterRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithRetries(2), WithBaseURL(server.URL))
+	recordSleeps(collector)
+
+	_, err := collector.fetchMetrics()
+	if err == nil || !strings.Contains(err.Error(), "status 503") {
+		t.Errorf("Expected status 503 error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_DoesNotRetryForbidden(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithRetries(3), WithBaseURL(server.URL))
+	recordSleeps(collector)
+
+	if _, err := collector.fetchMetrics(); err == nil {
+		t.Error("Expected error for forbidden response")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_HonoursRetryAfter(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithRetries(1), WithBaseURL(server.URL))
+	sleeps := recordSleeps(collector)
+
+	if _, err := collector.fetchMetrics(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(*sleeps) != 1 || (*sleeps)[0] != 7*time.Second {
+		t.Errorf("Expected a single 7s wait, got %v", *sleeps)
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_RateLimitBeyondMaxWait(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithRetries(3), WithMaxRateLimitWait(time.Minute), WithBaseURL(server.URL))
+	sleeps := recordSleeps(collector)
+
+	if _, err := collector.fetchMetrics(); err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("Expected status 403 error, got %v", err)
+	}
+	if len(*sleeps) != 0 {
+		t.Errorf("Expected no wait beyond the maximum, got %v", *sleeps)
+	}
+
+	rl, ok := collector.currentRateLimit()
+	if !ok || rl.limit != 5000 || rl.remaining != 0 || rl.reset.Unix() != reset {
+		t.Errorf("Unexpected recorded rate limit: %+v", rl)
+	}
+}
+
+func TestCopilotCollector_Collect_RateLimitMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4321")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	expected := `
+# HELP github_copilot_rate_limit_remaining GitHub API requests remaining in the current rate limit window, as last reported
+# TYPE github_copilot_rate_limit_remaining gauge
+github_copilot_rate_limit_remaining{org="test-org",scope="org",team=""} 4321
+# HELP github_copilot_rate_limit_reset_timestamp_seconds Unix timestamp at which the current GitHub API rate limit window resets
+# TYPE github_copilot_rate_limit_reset_timestamp_seconds gauge
+github_copilot_rate_limit_reset_timestamp_seconds{org="test-org",scope="org",team=""} 1.7e+09
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_rate_limit_remaining",
+		"github_copilot_rate_limit_reset_timestamp_seconds",
+	)
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+	}{
+		{
+			name:     "retry after seconds",
+			header:   http.Header{"Retry-After": []string{"30"}},
+			expected: 30 * time.Second,
+		},
+		{
+			name:     "retry after date",
+			header:   http.Header{"Retry-After": []string{now.Add(90 * time.Second).UTC().Format(http.TimeFormat)}},
+			expected: 90 * time.Second,
+		},
+		{
+			name: "exhausted budget waits for reset",
+			header: http.Header{
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{"1700000120"},
+			},
+			expected: 121 * time.Second,
+		},
+		{
+			name:     "no hint",
+			header:   http.Header{},
+			expected: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rateLimitWait(tt.header, now); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_ConditionalRequests(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 3}]`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	first, err := collector.fetchMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, err := collector.fetchMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error on revalidation: %v", err)
+	}
+
+	if len(conditional) != 2 || conditional[0] != "" || conditional[1] != `"v1"` {
+		t.Errorf("Expected an unconditional then a conditional request, got %q", conditional)
+	}
+	if len(second) != 1 || second[0].TotalActiveUsers != first[0].TotalActiveUsers {
+		t.Errorf("Expected cached metrics to be reused on 304, got %+v", second)
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_LastModified(t *testing.T) {
+	const lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		fmt.Fprint(w, `[{"day": "2024-01-01"}]`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	for i := 0; i < 2; i++ {
+		metrics, err := collector.fetchMetrics()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(metrics) != 1 {
+			t.Errorf("Fetch %d: expected 1 day, got %d", i, len(metrics))
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_UnexpectedNotModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	// Without a cached page there is nothing to reuse
+	if _, err := collector.fetchMetrics(); err == nil || !strings.Contains(err.Error(), "status 304") {
+		t.Errorf("Expected status 304 error, got %v", err)
+	}
+}
+
+func TestCopilotCollector_MetricsURL_BaseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		expected string
+	}{
+		{
+			name:     "GitHub Enterprise Server",
+			baseURL:  "https://github.example.com/api/v3",
+			expected: "https://github.example.com/api/v3/orgs/test-org/copilot/metrics?per_page=100",
+		},
+		{
+			name:     "GHE.com data residency with trailing slash",
+			baseURL:  "https://api.acme.ghe.com/",
+			expected: "https://api.acme.ghe.com/orgs/test-org/copilot/metrics?per_page=100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(tt.baseURL))
+			if got := collector.metricsURL(); got != tt.expected {
+				t.Errorf("Expected URL %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeBaseURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{input: "https://api.github.com", expected: "https://api.github.com"},
+		{input: "https://github.example.com/api/v3/", expected: "https://github.example.com/api/v3"},
+		{input: "http://localhost:8080", expected: "http://localhost:8080"},
+		{input: "api.github.com", wantErr: true},
+		{input: "ftp://github.example.com", wantErr: true},
+		{input: "https://github.example.com/api/v3?x=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeBaseURL(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil || got != tt.expected {
+			t.Errorf("%s: expected %s, got %s (%v)", tt.input, tt.expected, got, err)
+		}
+	}
+}
+
+func TestCopilotCollector_FetchMetrics_BaseURLPathPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/enterprises/test-enterprise/copilot/metrics" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `[{"day": "2024-01-01"}]`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "", "", "test-enterprise", WithBaseURL(server.URL+"/api/v3"))
+
+	metrics, err := collector.fetchMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Errorf("Expected 1 day, got %d", len(metrics))
+	}
+}
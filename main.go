@@ -1,12 +1,15 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,8 +17,20 @@ import (
 )
 
 const (
-	defaultPort     = "8082"
-	metricsEndpoint = "/metrics"
+	defaultPort         = "8082"
+	metricsEndpoint     = "/metrics"
+	probeEndpoint       = "/probe"
+	defaultGitHubAPIURL = "https://api.github.com"
+	defaultPollInterval = time.Hour
+	defaultMaxStaleness = 24 * time.Hour
+
+	defaultHTTPTimeout      = 10 * time.Second
+	defaultMaxRetries       = 3
+	defaultMaxRateLimitWait = 5 * time.Minute
+
+	// The metrics API returns at most 100 days of history, and at most 100 days per page
+	maxLookbackDays = 100
+	maxPerPage      = 100
 )
 
 // Breakdown represents breakdown of metrics by editor, language, or model
@@ -33,8 +48,81 @@ type Breakdown struct {
 	ActiveChatUsers  int    `json:"active_chat_users,omitempty"`
 }
 
+// CustomModelInfo represents the custom model metadata reported on every model entry
+type CustomModelInfo struct {
+	IsCustomModel           bool   `json:"is_custom_model,omitempty"`
+	CustomModelTrainingDate string `json:"custom_model_training_date,omitempty"`
+}
+
+// IDECodeCompletionsLanguage represents engaged users for a language across all IDE code completions
+type IDECodeCompletionsLanguage struct {
+	Name              string `json:"name"`
+	TotalEngagedUsers int    `json:"total_engaged_users,omitempty"`
+}
+
+// IDECodeCompletionsModelLanguage represents code completion activity for a language within an editor's model
+type IDECodeCompletionsModelLanguage struct {
+	Name                    string `json:"name"`
+	TotalEngagedUsers       int    `json:"total_engaged_users,omitempty"`
+	TotalCodeSuggestions    int    `json:"total_code_suggestions,omitempty"`
+	TotalCodeAcceptances    int    `json:"total_code_acceptances,omitempty"`
+	TotalCodeLinesSuggested int    `json:"total_code_lines_suggested,omitempty"`
+	TotalCodeLinesAccepted  int    `json:"total_code_lines_accepted,omitempty"`
+}
+
+// IDECodeCompletionsModel represents a model used for code completions within an editor
+type IDECodeCompletionsModel struct {
+	Name string `json:"name"`
+	CustomModelInfo
+	TotalEngagedUsers int                               `json:"total_engaged_users,omitempty"`
+	Languages         []IDECodeCompletionsModelLanguage `json:"languages,omitempty"`
+}
+
+// IDECodeCompletionsEditor represents code completion activity for an editor, broken down by model
+type IDECodeCompletionsEditor struct {
+	Name              string                    `json:"name"`
+	TotalEngagedUsers int                       `json:"total_engaged_users,omitempty"`
+	Models            []IDECodeCompletionsModel `json:"models,omitempty"`
+}
+
+// IDEChatModel represents IDE chat activity for a model within an editor
+type IDEChatModel struct {
+	Name string `json:"name"`
+	CustomModelInfo
+	TotalEngagedUsers        int `json:"total_engaged_users,omitempty"`
+	TotalChats               int `json:"total_chats,omitempty"`
+	TotalChatInsertionEvents int `json:"total_chat_insertion_events,omitempty"`
+	TotalChatCopyEvents      int `json:"total_chat_copy_events,omitempty"`
+}
+
+// IDEChatEditor represents IDE chat activity for an editor, broken down by model
+type IDEChatEditor struct {
+	Name              string         `json:"name"`
+	TotalEngagedUsers int            `json:"total_engaged_users,omitempty"`
+	Models            []IDEChatModel `json:"models,omitempty"`
+}
+
+// DotcomChatModel represents Copilot chat activity on GitHub.com for a model
+type DotcomChatModel struct {
+	Name string `json:"name"`
+	CustomModelInfo
+	TotalEngagedUsers int `json:"total_engaged_users,omitempty"`
+	TotalChats        int `json:"total_chats,omitempty"`
+}
+
+// DotcomPullRequestsModel represents pull request summary activity for a model within a repository
+type DotcomPullRequestsModel struct {
+	Name string `json:"name"`
+	CustomModelInfo
+	TotalEngagedUsers       int `json:"total_engaged_users,omitempty"`
+	TotalPRSummariesCreated int `json:"total_pr_summaries_created,omitempty"`
+}
+
 // CopilotAPIResponse represents the complete response from GitHub Copilot Metrics API
-type CopilotAPIResponse []struct {
+type CopilotAPIResponse []CopilotDayMetrics
+
+// CopilotDayMetrics represents the metrics of a single day
+type CopilotDayMetrics struct {
 	Day                   string `json:"day"`
 	TotalSuggestionsCount int    `json:"total_suggestions_count"`
 	TotalAcceptancesCount int    `json:"total_acceptances_count"`
@@ -50,34 +138,31 @@ type CopilotAPIResponse []struct {
 
 	// Copilot IDE Code Completions
 	CopilotIDECodeCompletions struct {
-		TotalEngagedUsers int         `json:"total_engaged_users,omitempty"`
-		Languages         []Breakdown `json:"languages,omitempty"`
-		Editors           []Breakdown `json:"editors,omitempty"`
-		Models            []Breakdown `json:"models,omitempty"`
+		TotalEngagedUsers int                          `json:"total_engaged_users,omitempty"`
+		Languages         []IDECodeCompletionsLanguage `json:"languages,omitempty"`
+		Editors           []IDECodeCompletionsEditor   `json:"editors,omitempty"`
 	} `json:"copilot_ide_code_completions,omitempty"`
 
 	// Copilot IDE Chat
 	CopilotIDEChat struct {
-		TotalEngagedUsers int         `json:"total_engaged_users,omitempty"`
-		Editors           []Breakdown `json:"editors,omitempty"`
-		Models            []Breakdown `json:"models,omitempty"`
+		TotalEngagedUsers int             `json:"total_engaged_users,omitempty"`
+		Editors           []IDEChatEditor `json:"editors,omitempty"`
 	} `json:"copilot_ide_chat,omitempty"`
 
 	// Copilot Dotcom Chat
 	CopilotDotcomChat struct {
-		TotalEngagedUsers int         `json:"total_engaged_users,omitempty"`
-		Models            []Breakdown `json:"models,omitempty"`
+		TotalEngagedUsers int               `json:"total_engaged_users,omitempty"`
+		Models            []DotcomChatModel `json:"models,omitempty"`
 	} `json:"copilot_dotcom_chat,omitempty"`
 
 	// Copilot Dotcom Pull Requests
 	CopilotDotcomPullRequests struct {
 		TotalEngagedUsers int `json:"total_engaged_users,omitempty"`
 		Repositories      []struct {
-			Name              string      `json:"name,omitempty"`
-			TotalEngagedUsers int         `json:"total_engaged_users,omitempty"`
-			Models            []Breakdown `json:"models,omitempty"`
+			Name              string                    `json:"name,omitempty"`
+			TotalEngagedUsers int                       `json:"total_engaged_users,omitempty"`
+			Models            []DotcomPullRequestsModel `json:"models,omitempty"`
 		} `json:"repositories,omitempty"`
-		Models []Breakdown `json:"models,omitempty"`
 	} `json:"copilot_dotcom_pull_requests,omitempty"`
 }
 
@@ -87,9 +172,67 @@ type CopilotCollector struct {
 	team         string
 	enterprise   string
 
+	// Supplies tokens instead of githubToken when set, e.g. for GitHub App authentication
+	tokenSource tokenSource
+
+	// GitHub API base URL, e.g. https://api.github.com or https://github.example.com/api/v3
+	baseURL string
+
+	// Query window and page size for the metrics API
+	lookbackDays int
+	since        time.Time
+	until        time.Time
+	perPage      int
+
+	httpClient *http.Client
+
+	// Retry policy for GitHub API requests
+	maxRetries       int
+	retryBaseDelay   time.Duration
+	retryMaxDelay    time.Duration
+	maxRateLimitWait time.Duration
+	sleep            func(time.Duration)
+
+	// Background polling; zero fetches on every scrape instead
+	pollInterval time.Duration
+
+	// Last successfully fetched metrics and the most recent fetch error, guarded by mu
+	mu           sync.RWMutex
+	snapshot     CopilotAPIResponse
+	snapshotTime time.Time
+	refreshErr   error
+	rateLimit    rateLimit
+	pageCache    map[string]cachedPage
+
+	// How long the last successful snapshot is served after fetches start failing; zero serves it indefinitely
+	maxStaleness time.Duration
+
+	// How the API's days are exposed: as a label, as sample timestamps, or only the latest complete day
+	dayMode DayMode
+
+	// Persists fetched days when set; the stored history is served instead of the API's window only
+	// when serveHistory is set
+	store        *Store
+	serveHistory bool
+
+	// Seats and billing information, fetched alongside the metrics when enabled; guarded by mu
+	seatsEnabled bool
+	seatPrices   map[string]float64
+	seats        *seatSnapshot
+	seatsTime    time.Time
+	seatsErr     error
+
 	// For testing: allows injection of mock data
 	testMetricsFetcher func() (CopilotAPIResponse, error)
 
+	// Exporter metrics
+	scrapeSuccess        *prometheus.Desc
+	lastSuccessTimestamp *prometheus.Desc
+	snapshotAge          *prometheus.Desc
+	rateLimitLimit       *prometheus.Desc
+	rateLimitRemaining   *prometheus.Desc
+	rateLimitReset       *prometheus.Desc
+
 	// Top-level metrics
 	totalSuggestions     *prometheus.Desc
 	totalAcceptances     *prometheus.Desc
@@ -111,167 +254,626 @@ type CopilotCollector struct {
 	breakdownChatTurns       *prometheus.Desc
 	breakdownActiveChatUsers *prometheus.Desc
 
+	// Model metadata
+	modelInfo *prometheus.Desc
+
 	// IDE Code Completions
-	ideCodeCompletionsEngagedUsers *prometheus.Desc
+	ideCodeCompletionsEngagedUsers              *prometheus.Desc
+	ideCodeCompletionsLanguageEngagedUsers      *prometheus.Desc
+	ideCodeCompletionsEditorEngagedUsers        *prometheus.Desc
+	ideCodeCompletionsModelEngagedUsers         *prometheus.Desc
+	ideCodeCompletionsModelLanguageEngagedUsers *prometheus.Desc
+	ideCodeCompletionsSuggestions               *prometheus.Desc
+	ideCodeCompletionsAcceptances               *prometheus.Desc
+	ideCodeCompletionsLinesSuggested            *prometheus.Desc
+	ideCodeCompletionsLinesAccepted             *prometheus.Desc
+	ideCodeCompletionsAcceptanceRate            *prometheus.Desc
 
 	// IDE Chat
-	ideChatEngagedUsers *prometheus.Desc
+	ideChatEngagedUsers       *prometheus.Desc
+	ideChatEditorEngagedUsers *prometheus.Desc
+	ideChatModelEngagedUsers  *prometheus.Desc
+	ideChatChats              *prometheus.Desc
+	ideChatInsertionEvents    *prometheus.Desc
+	ideChatCopyEvents         *prometheus.Desc
 
 	// Dotcom Chat
-	dotcomChatEngagedUsers *prometheus.Desc
+	dotcomChatEngagedUsers      *prometheus.Desc
+	dotcomChatModelEngagedUsers *prometheus.Desc
+	dotcomChatChats             *prometheus.Desc
 
 	// Dotcom Pull Requests
-	dotcomPREngagedUsers     *prometheus.Desc
-	dotcomPRRepoEngagedUsers *prometheus.Desc
+	dotcomPREngagedUsers          *prometheus.Desc
+	dotcomPRRepoEngagedUsers      *prometheus.Desc
+	dotcomPRRepoModelEngagedUsers *prometheus.Desc
+	dotcomPRSummariesCreated      *prometheus.Desc
+
+	// Seats and billing
+	seatsScrapeSuccess       *prometheus.Desc
+	seatsTotal               *prometheus.Desc
+	seatsActive              *prometheus.Desc
+	seatsInactive            *prometheus.Desc
+	seatsPendingInvitation   *prometheus.Desc
+	seatsPendingCancellation *prometheus.Desc
+	seatsAddedThisCycle      *prometheus.Desc
+	seatsAssigned            *prometheus.Desc
+	seatsIdle                *prometheus.Desc
+	seatsLastActivityEditor  *prometheus.Desc
+	billingInfo              *prometheus.Desc
+
+	// Enterprise seats by organization
+	organizationSeatsAssigned            *prometheus.Desc
+	organizationSeatsPendingCancellation *prometheus.Desc
+	organizationSeatsIdle                *prometheus.Desc
+
+	// Seat cost
+	seatsMonthlyCost    *prometheus.Desc
+	costPerActiveUser   *prometheus.Desc
+	costPerAcceptedLine *prometheus.Desc
+}
+
+// CollectorOption configures optional CopilotCollector behaviour
+type CollectorOption func(*CopilotCollector)
+
+// WithTokenSource authenticates API requests with tokens from ts instead of a static token
+func WithTokenSource(ts tokenSource) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.tokenSource = ts
+	}
+}
+
+// WithBaseURL sends API requests to a GitHub Enterprise Server, GHE.com or test server instead of api.github.com
+func WithBaseURL(baseURL string) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithLookbackDays requests the given number of days of history, ending today
+func WithLookbackDays(days int) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.lookbackDays = days
+	}
+}
+
+// WithTimeRange requests metrics between since and until; zero values leave that bound unset
+func WithTimeRange(since, until time.Time) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.since = since
+		c.until = until
+	}
+}
+
+// WithPollInterval refreshes metrics in the background at the given interval instead of on every scrape
+func WithPollInterval(interval time.Duration) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.pollInterval = interval
+	}
+}
+
+// WithMaxStaleness stops serving the last successful snapshot once it is older than maxStaleness
+func WithMaxStaleness(maxStaleness time.Duration) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.maxStaleness = maxStaleness
+	}
+}
+
+// WithHTTPTimeout sets the timeout for each GitHub API request attempt
+func WithHTTPTimeout(timeout time.Duration) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetries sets how many times failed GitHub API requests are retried
+func WithRetries(maxRetries int) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithMaxRateLimitWait sets the longest rate limit wait honoured before giving up on a request
+func WithMaxRateLimitWait(wait time.Duration) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.maxRateLimitWait = wait
+	}
+}
+
+// WithPerPage sets the number of days requested per page
+func WithPerPage(perPage int) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.perPage = perPage
+	}
+}
+
+// DayMode controls how the per-day values returned by the API are exposed
+type DayMode string
+
+const (
+	// DayModeLabel exports every day with a day label
+	DayModeLabel DayMode = "label"
+	// DayModeTimestamp exports every day without a day label, timestamped at the day's midnight UTC
+	DayModeTimestamp DayMode = "timestamp"
+	// DayModeLatest exports only the latest complete day without a day label, as current gauges
+	DayModeLatest DayMode = "latest"
+)
+
+// WithDayMode sets how the API's days are exposed
+func WithDayMode(mode DayMode) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.dayMode = mode
+	}
+}
+
+// WithStore persists every fetched day in store, including days GitHub later drops
+func WithStore(store *Store) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.store = store
+	}
+}
+
+// WithStoredHistory serves the whole stored history instead of the API's window. The number of series then
+// grows with the history, so it is meant for backfill, whose samples are timestamped.
+func WithStoredHistory(enabled bool) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.serveHistory = enabled
+	}
+}
+
+// WithSeats fetches the Copilot seats, and for organizations the billing information, alongside the metrics
+func WithSeats(enabled bool) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.seatsEnabled = enabled
+	}
 }
 
-func NewCopilotCollector(githubToken, organization, team, enterprise string) *CopilotCollector {
-	return &CopilotCollector{
+// WithSeatPrices sets the monthly price of a seat by plan type, e.g. business and enterprise, for the cost metrics
+func WithSeatPrices(prices map[string]float64) CollectorOption {
+	return func(c *CopilotCollector) {
+		c.seatPrices = prices
+	}
+}
+
+func NewCopilotCollector(githubToken, organization, team, enterprise string, opts ...CollectorOption) *CopilotCollector {
+	c := &CopilotCollector{
 		githubToken:  githubToken,
 		organization: organization,
 		team:         team,
 		enterprise:   enterprise,
-		totalSuggestions: prometheus.NewDesc(
-			"github_copilot_suggestions_total",
-			"Total number of Copilot suggestions",
-			[]string{"day", "org"},
-			nil,
-		),
-		totalAcceptances: prometheus.NewDesc(
-			"github_copilot_acceptances_total",
-			"Total number of Copilot acceptances",
-			[]string{"day", "org"},
-			nil,
-		),
-		totalLinesSuggested: prometheus.NewDesc(
-			"github_copilot_lines_suggested_total",
-			"Total number of lines suggested by Copilot",
-			[]string{"day", "org"},
-			nil,
-		),
-		totalLinesAccepted: prometheus.NewDesc(
-			"github_copilot_lines_accepted_total",
-			"Total number of lines accepted from Copilot",
-			[]string{"day", "org"},
-			nil,
-		),
-		totalActiveUsers: prometheus.NewDesc(
-			"github_copilot_active_users_total",
-			"Total number of active Copilot users",
-			[]string{"day", "org"},
-			nil,
-		),
-		totalChatAcceptances: prometheus.NewDesc(
-			"github_copilot_chat_acceptances_total",
-			"Total number of Copilot chat acceptances",
-			[]string{"day", "org"},
-			nil,
-		),
-		totalChatTurns: prometheus.NewDesc(
-			"github_copilot_chat_turns_total",
-			"Total number of Copilot chat turns",
-			[]string{"day", "org"},
-			nil,
-		),
-		totalActiveChatUsers: prometheus.NewDesc(
-			"github_copilot_active_chat_users_total",
-			"Total number of active Copilot chat users",
-			[]string{"day", "org"},
-			nil,
-		),
-		acceptanceRate: prometheus.NewDesc(
-			"github_copilot_acceptance_rate",
-			"Copilot acceptance rate (acceptances/suggestions)",
-			[]string{"day", "org"},
-			nil,
-		),
-		// Breakdown metrics with language, editor, and model labels
-		breakdownSuggestions: prometheus.NewDesc(
-			"github_copilot_breakdown_suggestions_total",
-			"Copilot suggestions by language, editor, or model",
-			[]string{"day", "org", "language", "editor", "model"},
-			nil,
-		),
-		breakdownAcceptances: prometheus.NewDesc(
-			"github_copilot_breakdown_acceptances_total",
-			"Copilot acceptances by language, editor, or model",
-			[]string{"day", "org", "language", "editor", "model"},
-			nil,
-		),
-		breakdownLinesSuggested: prometheus.NewDesc(
-			"github_copilot_breakdown_lines_suggested_total",
-			"Lines suggested by language, editor, or model",
-			[]string{"day", "org", "language", "editor", "model"},
-			nil,
-		),
-		breakdownLinesAccepted: prometheus.NewDesc(
-			"github_copilot_breakdown_lines_accepted_total",
-			"Lines accepted by language, editor, or model",
-			[]string{"day", "org", "language", "editor", "model"},
-			nil,
-		),
-		breakdownActiveUsers: prometheus.NewDesc(
-			"github_copilot_breakdown_active_users",
-			"Active users by language, editor, or model",
-			[]string{"day", "org", "language", "editor", "model"},
-			nil,
-		),
-		breakdownChatAcceptances: prometheus.NewDesc(
-			"github_copilot_breakdown_chat_acceptances_total",
-			"Chat acceptances by language, editor, or model",
-			[]string{"day", "org", "language", "editor", "model"},
-			nil,
-		),
-		breakdownChatTurns: prometheus.NewDesc(
-			"github_copilot_breakdown_chat_turns_total",
-			"Chat turns by language, editor, or model",
-			[]string{"day", "org", "language", "editor", "model"},
-			nil,
-		),
-		breakdownActiveChatUsers: prometheus.NewDesc(
-			"github_copilot_breakdown_active_chat_users",
-			"Active chat users by language, editor, or model",
-			[]string{"day", "org", "language", "editor", "model"},
-			nil,
-		),
-		// IDE Code Completions
-		ideCodeCompletionsEngagedUsers: prometheus.NewDesc(
-			"github_copilot_ide_code_completions_engaged_users",
-			"Total engaged users for IDE code completions",
-			[]string{"day", "org"},
-			nil,
-		),
-		// IDE Chat
-		ideChatEngagedUsers: prometheus.NewDesc(
-			"github_copilot_ide_chat_engaged_users",
-			"Total engaged users for IDE chat",
-			[]string{"day", "org"},
-			nil,
-		),
-		// Dotcom Chat
-		dotcomChatEngagedUsers: prometheus.NewDesc(
-			"github_copilot_dotcom_chat_engaged_users",
-			"Total engaged users for Dotcom chat",
-			[]string{"day", "org"},
-			nil,
-		),
-		// Dotcom Pull Requests
-		dotcomPREngagedUsers: prometheus.NewDesc(
-			"github_copilot_dotcom_pr_engaged_users",
-			"Total engaged users for Dotcom pull requests",
-			[]string{"day", "org"},
-			nil,
-		),
-		dotcomPRRepoEngagedUsers: prometheus.NewDesc(
-			"github_copilot_dotcom_pr_repo_engaged_users",
-			"Engaged users for Dotcom pull requests by repository",
-			[]string{"day", "org", "repository"},
-			nil,
-		),
+		baseURL:      defaultGitHubAPIURL,
+		perPage:      maxPerPage,
+		httpClient:   &http.Client{Timeout: defaultHTTPTimeout},
+
+		retryBaseDelay:   time.Second,
+		retryMaxDelay:    30 * time.Second,
+		maxRateLimitWait: defaultMaxRateLimitWait,
+		sleep:            time.Sleep,
+		dayMode:          DayModeLabel,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// The target is constant for a collector. Every series carries org, team (empty unless team
+	// scope) and scope, so collectors for different targets never share a label set.
+	constLabels := prometheus.Labels{
+		"org":   c.orgLabel(),
+		"team":  team,
+		"scope": Target{Organization: organization, Team: team, Enterprise: enterprise}.Scope(),
 	}
+
+	// The day is a label only in DayModeLabel; the other modes carry it in the timestamp or drop it
+	dayLabels := func(labels ...string) []string {
+		if c.dayMode == DayModeLabel {
+			return append([]string{"day"}, labels...)
+		}
+		return labels
+	}
+
+	c.scrapeSuccess = prometheus.NewDesc(
+		"github_copilot_scrape_success",
+		"Whether the most recent fetch from the GitHub API succeeded (1) or failed (0)",
+		nil,
+		constLabels,
+	)
+	c.lastSuccessTimestamp = prometheus.NewDesc(
+		"github_copilot_last_success_timestamp_seconds",
+		"Unix timestamp of the last successful fetch from the GitHub API",
+		nil,
+		constLabels,
+	)
+	c.snapshotAge = prometheus.NewDesc(
+		"github_copilot_snapshot_age_seconds",
+		"Seconds since the served metrics were fetched from the GitHub API",
+		nil,
+		constLabels,
+	)
+	c.rateLimitLimit = prometheus.NewDesc(
+		"github_copilot_rate_limit_limit",
+		"GitHub API rate limit for the exporter's credentials, as last reported",
+		nil,
+		constLabels,
+	)
+	c.rateLimitRemaining = prometheus.NewDesc(
+		"github_copilot_rate_limit_remaining",
+		"GitHub API requests remaining in the current rate limit window, as last reported",
+		nil,
+		constLabels,
+	)
+	c.rateLimitReset = prometheus.NewDesc(
+		"github_copilot_rate_limit_reset_timestamp_seconds",
+		"Unix timestamp at which the current GitHub API rate limit window resets",
+		nil,
+		constLabels,
+	)
+	c.totalSuggestions = prometheus.NewDesc(
+		"github_copilot_suggestions_total",
+		"Total number of Copilot suggestions",
+		dayLabels(),
+		constLabels,
+	)
+	c.totalAcceptances = prometheus.NewDesc(
+		"github_copilot_acceptances_total",
+		"Total number of Copilot acceptances",
+		dayLabels(),
+		constLabels,
+	)
+	c.totalLinesSuggested = prometheus.NewDesc(
+		"github_copilot_lines_suggested_total",
+		"Total number of lines suggested by Copilot",
+		dayLabels(),
+		constLabels,
+	)
+	c.totalLinesAccepted = prometheus.NewDesc(
+		"github_copilot_lines_accepted_total",
+		"Total number of lines accepted from Copilot",
+		dayLabels(),
+		constLabels,
+	)
+	c.totalActiveUsers = prometheus.NewDesc(
+		"github_copilot_active_users_total",
+		"Total number of active Copilot users",
+		dayLabels(),
+		constLabels,
+	)
+	c.totalChatAcceptances = prometheus.NewDesc(
+		"github_copilot_chat_acceptances_total",
+		"Total number of Copilot chat acceptances",
+		dayLabels(),
+		constLabels,
+	)
+	c.totalChatTurns = prometheus.NewDesc(
+		"github_copilot_chat_turns_total",
+		"Total number of Copilot chat turns",
+		dayLabels(),
+		constLabels,
+	)
+	c.totalActiveChatUsers = prometheus.NewDesc(
+		"github_copilot_active_chat_users_total",
+		"Total number of active Copilot chat users",
+		dayLabels(),
+		constLabels,
+	)
+	c.acceptanceRate = prometheus.NewDesc(
+		"github_copilot_acceptance_rate",
+		"Copilot acceptance rate (acceptances/suggestions)",
+		dayLabels(),
+		constLabels,
+	)
+	// Breakdown metrics with language, editor, and model labels
+	c.breakdownSuggestions = prometheus.NewDesc(
+		"github_copilot_breakdown_suggestions_total",
+		"Copilot suggestions by language, editor, or model",
+		dayLabels("language", "editor", "model"),
+		constLabels,
+	)
+	c.breakdownAcceptances = prometheus.NewDesc(
+		"github_copilot_breakdown_acceptances_total",
+		"Copilot acceptances by language, editor, or model",
+		dayLabels("language", "editor", "model"),
+		constLabels,
+	)
+	c.breakdownLinesSuggested = prometheus.NewDesc(
+		"github_copilot_breakdown_lines_suggested_total",
+		"Lines suggested by language, editor, or model",
+		dayLabels("language", "editor", "model"),
+		constLabels,
+	)
+	c.breakdownLinesAccepted = prometheus.NewDesc(
+		"github_copilot_breakdown_lines_accepted_total",
+		"Lines accepted by language, editor, or model",
+		dayLabels("language", "editor", "model"),
+		constLabels,
+	)
+	c.breakdownActiveUsers = prometheus.NewDesc(
+		"github_copilot_breakdown_active_users",
+		"Active users by language, editor, or model",
+		dayLabels("language", "editor", "model"),
+		constLabels,
+	)
+	c.breakdownChatAcceptances = prometheus.NewDesc(
+		"github_copilot_breakdown_chat_acceptances_total",
+		"Chat acceptances by language, editor, or model",
+		dayLabels("language", "editor", "model"),
+		constLabels,
+	)
+	c.breakdownChatTurns = prometheus.NewDesc(
+		"github_copilot_breakdown_chat_turns_total",
+		"Chat turns by language, editor, or model",
+		dayLabels("language", "editor", "model"),
+		constLabels,
+	)
+	c.breakdownActiveChatUsers = prometheus.NewDesc(
+		"github_copilot_breakdown_active_chat_users",
+		"Active chat users by language, editor, or model",
+		dayLabels("language", "editor", "model"),
+		constLabels,
+	)
+	// Model metadata
+	c.modelInfo = prometheus.NewDesc(
+		"github_copilot_model_info",
+		"Copilot model metadata, including whether it is a custom model and its training date",
+		[]string{"model", "is_custom_model", "custom_model_training_date"},
+		constLabels,
+	)
+	// IDE Code Completions
+	c.ideCodeCompletionsEngagedUsers = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_engaged_users",
+		"Total engaged users for IDE code completions",
+		dayLabels(),
+		constLabels,
+	)
+	c.ideCodeCompletionsLanguageEngagedUsers = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_language_engaged_users",
+		"Engaged users for IDE code completions by language",
+		dayLabels("language"),
+		constLabels,
+	)
+	c.ideCodeCompletionsEditorEngagedUsers = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_editor_engaged_users",
+		"Engaged users for IDE code completions by editor",
+		dayLabels("editor"),
+		constLabels,
+	)
+	c.ideCodeCompletionsModelEngagedUsers = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_model_engaged_users",
+		"Engaged users for IDE code completions by editor and model",
+		dayLabels("editor", "model", "is_custom_model"),
+		constLabels,
+	)
+	c.ideCodeCompletionsModelLanguageEngagedUsers = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_model_language_engaged_users",
+		"Engaged users for IDE code completions by editor, model and language",
+		dayLabels("editor", "model", "is_custom_model", "language"),
+		constLabels,
+	)
+	c.ideCodeCompletionsSuggestions = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_suggestions_total",
+		"IDE code completion suggestions by editor, model and language",
+		dayLabels("editor", "model", "is_custom_model", "language"),
+		constLabels,
+	)
+	c.ideCodeCompletionsAcceptances = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_acceptances_total",
+		"IDE code completion acceptances by editor, model and language",
+		dayLabels("editor", "model", "is_custom_model", "language"),
+		constLabels,
+	)
+	c.ideCodeCompletionsLinesSuggested = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_lines_suggested_total",
+		"IDE code completion lines suggested by editor, model and language",
+		dayLabels("editor", "model", "is_custom_model", "language"),
+		constLabels,
+	)
+	c.ideCodeCompletionsLinesAccepted = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_lines_accepted_total",
+		"IDE code completion lines accepted by editor, model and language",
+		dayLabels("editor", "model", "is_custom_model", "language"),
+		constLabels,
+	)
+	c.ideCodeCompletionsAcceptanceRate = prometheus.NewDesc(
+		"github_copilot_ide_code_completions_acceptance_rate",
+		"IDE code completion acceptance rate (acceptances/suggestions) by editor, model and language",
+		dayLabels("editor", "model", "is_custom_model", "language"),
+		constLabels,
+	)
+	// IDE Chat
+	c.ideChatEngagedUsers = prometheus.NewDesc(
+		"github_copilot_ide_chat_engaged_users",
+		"Total engaged users for IDE chat",
+		dayLabels(),
+		constLabels,
+	)
+	c.ideChatEditorEngagedUsers = prometheus.NewDesc(
+		"github_copilot_ide_chat_editor_engaged_users",
+		"Engaged users for IDE chat by editor",
+		dayLabels("editor"),
+		constLabels,
+	)
+	c.ideChatModelEngagedUsers = prometheus.NewDesc(
+		"github_copilot_ide_chat_model_engaged_users",
+		"Engaged users for IDE chat by editor and model",
+		dayLabels("editor", "model", "is_custom_model"),
+		constLabels,
+	)
+	c.ideChatChats = prometheus.NewDesc(
+		"github_copilot_ide_chat_chats_total",
+		"IDE chats by editor and model",
+		dayLabels("editor", "model", "is_custom_model"),
+		constLabels,
+	)
+	c.ideChatInsertionEvents = prometheus.NewDesc(
+		"github_copilot_ide_chat_insertion_events_total",
+		"IDE chat code insertion events by editor and model",
+		dayLabels("editor", "model", "is_custom_model"),
+		constLabels,
+	)
+	c.ideChatCopyEvents = prometheus.NewDesc(
+		"github_copilot_ide_chat_copy_events_total",
+		"IDE chat copy events by editor and model",
+		dayLabels("editor", "model", "is_custom_model"),
+		constLabels,
+	)
+	// Dotcom Chat
+	c.dotcomChatEngagedUsers = prometheus.NewDesc(
+		"github_copilot_dotcom_chat_engaged_users",
+		"Total engaged users for Dotcom chat",
+		dayLabels(),
+		constLabels,
+	)
+	c.dotcomChatModelEngagedUsers = prometheus.NewDesc(
+		"github_copilot_dotcom_chat_model_engaged_users",
+		"Engaged users for Dotcom chat by model",
+		dayLabels("model", "is_custom_model"),
+		constLabels,
+	)
+	c.dotcomChatChats = prometheus.NewDesc(
+		"github_copilot_dotcom_chat_chats_total",
+		"Dotcom chats by model",
+		dayLabels("model", "is_custom_model"),
+		constLabels,
+	)
+	// Dotcom Pull Requests
+	c.dotcomPREngagedUsers = prometheus.NewDesc(
+		"github_copilot_dotcom_pr_engaged_users",
+		"Total engaged users for Dotcom pull requests",
+		dayLabels(),
+		constLabels,
+	)
+	c.dotcomPRRepoEngagedUsers = prometheus.NewDesc(
+		"github_copilot_dotcom_pr_repo_engaged_users",
+		"Engaged users for Dotcom pull requests by repository",
+		dayLabels("repository"),
+		constLabels,
+	)
+	c.dotcomPRRepoModelEngagedUsers = prometheus.NewDesc(
+		"github_copilot_dotcom_pr_repo_model_engaged_users",
+		"Engaged users for Dotcom pull requests by repository and model",
+		dayLabels("repository", "model", "is_custom_model"),
+		constLabels,
+	)
+	c.dotcomPRSummariesCreated = prometheus.NewDesc(
+		"github_copilot_dotcom_pr_summaries_created",
+		"Pull request summaries created by Copilot by repository and model",
+		dayLabels("repository", "model", "is_custom_model"),
+		constLabels,
+	)
+
+	// Seat and billing metrics describe the current billing cycle, so they never carry a day
+	c.seatsScrapeSuccess = prometheus.NewDesc(
+		"github_copilot_seats_scrape_success",
+		"Whether the most recent fetch from the GitHub Copilot billing API succeeded (1) or failed (0)",
+		nil,
+		constLabels,
+	)
+	c.seatsTotal = prometheus.NewDesc(
+		"github_copilot_seats_total",
+		"Total number of Copilot seats billed in the current billing cycle",
+		nil,
+		constLabels,
+	)
+	c.seatsActive = prometheus.NewDesc(
+		"github_copilot_seats_active",
+		"Copilot seats used in the current billing cycle",
+		nil,
+		constLabels,
+	)
+	c.seatsInactive = prometheus.NewDesc(
+		"github_copilot_seats_inactive",
+		"Copilot seats not used in the current billing cycle",
+		nil,
+		constLabels,
+	)
+	c.seatsPendingInvitation = prometheus.NewDesc(
+		"github_copilot_seats_pending_invitation",
+		"Copilot seats assigned to users who have not accepted their organization invitation",
+		nil,
+		constLabels,
+	)
+	c.seatsPendingCancellation = prometheus.NewDesc(
+		"github_copilot_seats_pending_cancellation",
+		"Copilot seats that will be removed at the end of the current billing cycle",
+		nil,
+		constLabels,
+	)
+	c.seatsAddedThisCycle = prometheus.NewDesc(
+		"github_copilot_seats_added_this_cycle",
+		"Copilot seats added in the current billing cycle",
+		nil,
+		constLabels,
+	)
+	c.seatsAssigned = prometheus.NewDesc(
+		"github_copilot_seats_assigned",
+		"Copilot seats listed by the seats API by plan type",
+		[]string{"plan_type"},
+		constLabels,
+	)
+	c.seatsIdle = prometheus.NewDesc(
+		"github_copilot_seats_idle",
+		"Copilot seats without activity for at least idle_days days, including never used seats; idle_days=never counts only those",
+		[]string{"idle_days"},
+		constLabels,
+	)
+	c.seatsLastActivityEditor = prometheus.NewDesc(
+		"github_copilot_seats_last_activity_editor",
+		"Copilot seats by the editor of their last activity",
+		[]string{"editor"},
+		constLabels,
+	)
+	c.billingInfo = prometheus.NewDesc(
+		"github_copilot_billing_info",
+		"Copilot plan and seat management setting of the organization, always 1",
+		[]string{"plan_type", "seat_management_setting"},
+		constLabels,
+	)
+	c.organizationSeatsAssigned = prometheus.NewDesc(
+		"github_copilot_organization_seats_assigned",
+		"Copilot seats of an enterprise by the organization granting them",
+		[]string{"organization"},
+		constLabels,
+	)
+	c.organizationSeatsPendingCancellation = prometheus.NewDesc(
+		"github_copilot_organization_seats_pending_cancellation",
+		"Copilot seats of an enterprise pending cancellation by the organization granting them",
+		[]string{"organization"},
+		constLabels,
+	)
+	c.organizationSeatsIdle = prometheus.NewDesc(
+		"github_copilot_organization_seats_idle",
+		"Copilot seats of an enterprise without activity for at least idle_days days by the organization granting them; idle_days=never counts only never used seats",
+		[]string{"organization", "idle_days"},
+		constLabels,
+	)
+	c.seatsMonthlyCost = prometheus.NewDesc(
+		"github_copilot_seats_monthly_cost",
+		"Monthly cost of the Copilot seats by plan type, at the configured seat price",
+		[]string{"plan_type"},
+		constLabels,
+	)
+	c.costPerActiveUser = prometheus.NewDesc(
+		"github_copilot_cost_per_active_user",
+		"Daily share of the Copilot seat cost divided by the active users of that day",
+		dayLabels(),
+		constLabels,
+	)
+	c.costPerAcceptedLine = prometheus.NewDesc(
+		"github_copilot_cost_per_accepted_line",
+		"Daily share of the Copilot seat cost divided by the lines accepted that day",
+		dayLabels(),
+		constLabels,
+	)
+
+	return c
 }
 
 func (c *CopilotCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.scrapeSuccess
+	ch <- c.lastSuccessTimestamp
+	ch <- c.snapshotAge
+	ch <- c.rateLimitLimit
+	ch <- c.rateLimitRemaining
+	ch <- c.rateLimitReset
 	ch <- c.totalSuggestions
 	ch <- c.totalAcceptances
 	ch <- c.totalLinesSuggested
@@ -289,86 +891,166 @@ func (c *CopilotCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.breakdownChatAcceptances
 	ch <- c.breakdownChatTurns
 	ch <- c.breakdownActiveChatUsers
+	ch <- c.modelInfo
 	ch <- c.ideCodeCompletionsEngagedUsers
+	ch <- c.ideCodeCompletionsLanguageEngagedUsers
+	ch <- c.ideCodeCompletionsEditorEngagedUsers
+	ch <- c.ideCodeCompletionsModelEngagedUsers
+	ch <- c.ideCodeCompletionsModelLanguageEngagedUsers
+	ch <- c.ideCodeCompletionsSuggestions
+	ch <- c.ideCodeCompletionsAcceptances
+	ch <- c.ideCodeCompletionsLinesSuggested
+	ch <- c.ideCodeCompletionsLinesAccepted
+	ch <- c.ideCodeCompletionsAcceptanceRate
 	ch <- c.ideChatEngagedUsers
+	ch <- c.ideChatEditorEngagedUsers
+	ch <- c.ideChatModelEngagedUsers
+	ch <- c.ideChatChats
+	ch <- c.ideChatInsertionEvents
+	ch <- c.ideChatCopyEvents
 	ch <- c.dotcomChatEngagedUsers
+	ch <- c.dotcomChatModelEngagedUsers
+	ch <- c.dotcomChatChats
 	ch <- c.dotcomPREngagedUsers
 	ch <- c.dotcomPRRepoEngagedUsers
+	ch <- c.dotcomPRRepoModelEngagedUsers
+	ch <- c.dotcomPRSummariesCreated
+	ch <- c.seatsScrapeSuccess
+	ch <- c.seatsTotal
+	ch <- c.seatsActive
+	ch <- c.seatsInactive
+	ch <- c.seatsPendingInvitation
+	ch <- c.seatsPendingCancellation
+	ch <- c.seatsAddedThisCycle
+	ch <- c.seatsAssigned
+	ch <- c.seatsIdle
+	ch <- c.seatsLastActivityEditor
+	ch <- c.billingInfo
+	ch <- c.organizationSeatsAssigned
+	ch <- c.organizationSeatsPendingCancellation
+	ch <- c.organizationSeatsIdle
+	ch <- c.seatsMonthlyCost
+	ch <- c.costPerActiveUser
+	ch <- c.costPerAcceptedLine
 }
 
 func (c *CopilotCollector) Collect(ch chan<- prometheus.Metric) {
-	// Fetch fresh metrics on every scrape - no caching
-	var metrics CopilotAPIResponse
-	var err error
+	if c.pollInterval == 0 {
+		// Fetch fresh metrics on every scrape when background polling is disabled
+		if err := c.refresh(); err != nil {
+			log.Printf("Error fetching metrics for %s: %v", c.target(), err)
+		}
+	}
 
-	if c.testMetricsFetcher != nil {
-		// Use test fetcher for testing
-		metrics, err = c.testMetricsFetcher()
-	} else {
-		// Use real API in production
-		metrics, err = c.fetchMetrics()
+	c.collectSeats(ch)
+
+	metrics, updated, err := c.currentSnapshot()
+
+	success := 0.0
+	if err == nil && !updated.IsZero() {
+		success = 1
 	}
+	ch <- prometheus.MustNewConstMetric(
+		c.scrapeSuccess,
+		prometheus.GaugeValue,
+		success,
+	)
 
-	if err != nil {
-		log.Printf("Error fetching metrics: %v", err)
+	if rl, ok := c.currentRateLimit(); ok {
+		ch <- prometheus.MustNewConstMetric(
+			c.rateLimitLimit,
+			prometheus.GaugeValue,
+			float64(rl.limit),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.rateLimitRemaining,
+			prometheus.GaugeValue,
+			float64(rl.remaining),
+		)
+		if !rl.reset.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				c.rateLimitReset,
+				prometheus.GaugeValue,
+				float64(rl.reset.Unix()),
+			)
+		}
+	}
+
+	if updated.IsZero() {
+		// No successful fetch yet
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.lastSuccessTimestamp,
+		prometheus.GaugeValue,
+		float64(updated.Unix()),
+	)
+
+	age := time.Since(updated)
+	ch <- prometheus.MustNewConstMetric(
+		c.snapshotAge,
+		prometheus.GaugeValue,
+		age.Seconds(),
+	)
+
+	if c.isStale(updated) {
+		// Last good data is too old to be served
 		return
 	}
 
+	metrics = c.exportedDays(metrics)
 	for _, metric := range metrics {
 		day := metric.Day
-		org := c.organization
-		if c.enterprise != "" {
-			org = c.enterprise
-		}
 
 		// Top-level aggregate metrics
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.totalSuggestions,
 			prometheus.GaugeValue,
 			float64(metric.TotalSuggestionsCount),
-			day, org,
+			day,
 		)
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.totalAcceptances,
 			prometheus.GaugeValue,
 			float64(metric.TotalAcceptancesCount),
-			day, org,
+			day,
 		)
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.totalLinesSuggested,
 			prometheus.GaugeValue,
 			float64(metric.TotalLinesSuggested),
-			day, org,
+			day,
 		)
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.totalLinesAccepted,
 			prometheus.GaugeValue,
 			float64(metric.TotalLinesAccepted),
-			day, org,
+			day,
 		)
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.totalActiveUsers,
 			prometheus.GaugeValue,
 			float64(metric.TotalActiveUsers),
-			day, org,
+			day,
 		)
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.totalChatAcceptances,
 			prometheus.GaugeValue,
 			float64(metric.TotalChatAcceptances),
-			day, org,
+			day,
 		)
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.totalChatTurns,
 			prometheus.GaugeValue,
 			float64(metric.TotalChatTurns),
-			day, org,
+			day,
 		)
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.totalActiveChatUsers,
 			prometheus.GaugeValue,
 			float64(metric.TotalActiveChatUsers),
-			day, org,
+			day,
 		)
 
 		// Calculate acceptance rate
@@ -376,11 +1058,11 @@ func (c *CopilotCollector) Collect(ch chan<- prometheus.Metric) {
 		if metric.TotalSuggestionsCount > 0 {
 			acceptanceRate = float64(metric.TotalAcceptancesCount) / float64(metric.TotalSuggestionsCount)
 		}
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.acceptanceRate,
 			prometheus.GaugeValue,
 			acceptanceRate,
-			day, org,
+			day,
 		)
 
 		// Breakdown metrics (generic breakdown array)
@@ -390,167 +1072,434 @@ func (c *CopilotCollector) Collect(ch chan<- prometheus.Metric) {
 			model := breakdown.Model
 
 			if breakdown.SuggestionsCount > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.breakdownSuggestions,
 					prometheus.GaugeValue,
 					float64(breakdown.SuggestionsCount),
-					day, org, language, editor, model,
+					day, language, editor, model,
 				)
 			}
 			if breakdown.AcceptancesCount > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.breakdownAcceptances,
 					prometheus.GaugeValue,
 					float64(breakdown.AcceptancesCount),
-					day, org, language, editor, model,
+					day, language, editor, model,
 				)
 			}
 			if breakdown.LinesSuggested > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.breakdownLinesSuggested,
 					prometheus.GaugeValue,
 					float64(breakdown.LinesSuggested),
-					day, org, language, editor, model,
+					day, language, editor, model,
 				)
 			}
 			if breakdown.LinesAccepted > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.breakdownLinesAccepted,
 					prometheus.GaugeValue,
 					float64(breakdown.LinesAccepted),
-					day, org, language, editor, model,
+					day, language, editor, model,
 				)
 			}
 			if breakdown.ActiveUsers > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.breakdownActiveUsers,
 					prometheus.GaugeValue,
 					float64(breakdown.ActiveUsers),
-					day, org, language, editor, model,
+					day, language, editor, model,
 				)
 			}
 			if breakdown.ChatAcceptances > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.breakdownChatAcceptances,
 					prometheus.GaugeValue,
 					float64(breakdown.ChatAcceptances),
-					day, org, language, editor, model,
+					day, language, editor, model,
 				)
 			}
 			if breakdown.ChatTurns > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.breakdownChatTurns,
 					prometheus.GaugeValue,
 					float64(breakdown.ChatTurns),
-					day, org, language, editor, model,
+					day, language, editor, model,
 				)
 			}
 			if breakdown.ActiveChatUsers > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.breakdownActiveChatUsers,
 					prometheus.GaugeValue,
 					float64(breakdown.ActiveChatUsers),
-					day, org, language, editor, model,
+					day, language, editor, model,
 				)
 			}
 		}
 
 		// IDE Code Completions
 		if metric.CopilotIDECodeCompletions.TotalEngagedUsers > 0 {
-			ch <- prometheus.MustNewConstMetric(
+			ch <- c.dailyMetric(
 				c.ideCodeCompletionsEngagedUsers,
 				prometheus.GaugeValue,
 				float64(metric.CopilotIDECodeCompletions.TotalEngagedUsers),
-				day, org,
+				day,
 			)
 		}
 
-		// IDE Code Completions - Languages breakdown
+		// IDE Code Completions - Languages engagement
 		for _, lang := range metric.CopilotIDECodeCompletions.Languages {
-			c.exportBreakdown(ch, day, org, lang, "language")
+			if lang.TotalEngagedUsers > 0 {
+				ch <- c.dailyMetric(
+					c.ideCodeCompletionsLanguageEngagedUsers,
+					prometheus.GaugeValue,
+					float64(lang.TotalEngagedUsers),
+					day, labelOrUnknown(lang.Name),
+				)
+			}
 		}
 
-		// IDE Code Completions - Editors breakdown
+		// IDE Code Completions - Editors -> Models -> Languages
 		for _, editor := range metric.CopilotIDECodeCompletions.Editors {
-			c.exportBreakdown(ch, day, org, editor, "editor")
-		}
-
-		// IDE Code Completions - Models breakdown
-		for _, model := range metric.CopilotIDECodeCompletions.Models {
-			c.exportBreakdown(ch, day, org, model, "model")
+			c.exportIDECodeCompletionsEditor(ch, day, editor)
 		}
 
 		// IDE Chat
 		if metric.CopilotIDEChat.TotalEngagedUsers > 0 {
-			ch <- prometheus.MustNewConstMetric(
+			ch <- c.dailyMetric(
 				c.ideChatEngagedUsers,
 				prometheus.GaugeValue,
 				float64(metric.CopilotIDEChat.TotalEngagedUsers),
-				day, org,
+				day,
 			)
 		}
 
-		// IDE Chat - Editors breakdown
+		// IDE Chat - Editors -> Models
 		for _, editor := range metric.CopilotIDEChat.Editors {
-			c.exportBreakdown(ch, day, org, editor, "editor")
-		}
-
-		// IDE Chat - Models breakdown
-		for _, model := range metric.CopilotIDEChat.Models {
-			c.exportBreakdown(ch, day, org, model, "model")
+			c.exportIDEChatEditor(ch, day, editor)
 		}
 
 		// Dotcom Chat
 		if metric.CopilotDotcomChat.TotalEngagedUsers > 0 {
-			ch <- prometheus.MustNewConstMetric(
+			ch <- c.dailyMetric(
 				c.dotcomChatEngagedUsers,
 				prometheus.GaugeValue,
 				float64(metric.CopilotDotcomChat.TotalEngagedUsers),
-				day, org,
+				day,
 			)
 		}
 
 		// Dotcom Chat - Models breakdown
 		for _, model := range metric.CopilotDotcomChat.Models {
-			c.exportBreakdown(ch, day, org, model, "model")
+			modelName := labelOrUnknown(model.Name)
+			isCustom := strconv.FormatBool(model.IsCustomModel)
+
+			if model.TotalEngagedUsers > 0 {
+				ch <- c.dailyMetric(
+					c.dotcomChatModelEngagedUsers,
+					prometheus.GaugeValue,
+					float64(model.TotalEngagedUsers),
+					day, modelName, isCustom,
+				)
+			}
+			ch <- c.dailyMetric(
+				c.dotcomChatChats,
+				prometheus.GaugeValue,
+				float64(model.TotalChats),
+				day, modelName, isCustom,
+			)
 		}
 
 		// Dotcom Pull Requests
 		if metric.CopilotDotcomPullRequests.TotalEngagedUsers > 0 {
-			ch <- prometheus.MustNewConstMetric(
+			ch <- c.dailyMetric(
 				c.dotcomPREngagedUsers,
 				prometheus.GaugeValue,
 				float64(metric.CopilotDotcomPullRequests.TotalEngagedUsers),
-				day, org,
+				day,
 			)
 		}
 
 		// Dotcom Pull Requests - Repositories
 		for _, repo := range metric.CopilotDotcomPullRequests.Repositories {
 			if repo.TotalEngagedUsers > 0 {
-				ch <- prometheus.MustNewConstMetric(
+				ch <- c.dailyMetric(
 					c.dotcomPRRepoEngagedUsers,
 					prometheus.GaugeValue,
 					float64(repo.TotalEngagedUsers),
-					day, org, repo.Name,
+					day, repo.Name,
 				)
 			}
 
 			// Repository models breakdown
 			for _, model := range repo.Models {
-				c.exportBreakdown(ch, day, org, model, "model")
+				modelName := labelOrUnknown(model.Name)
+				isCustom := strconv.FormatBool(model.IsCustomModel)
+
+				if model.TotalEngagedUsers > 0 {
+					ch <- c.dailyMetric(
+						c.dotcomPRRepoModelEngagedUsers,
+						prometheus.GaugeValue,
+						float64(model.TotalEngagedUsers),
+						day, repo.Name, modelName, isCustom,
+					)
+				}
+				ch <- c.dailyMetric(
+					c.dotcomPRSummariesCreated,
+					prometheus.GaugeValue,
+					float64(model.TotalPRSummariesCreated),
+					day, repo.Name, modelName, isCustom,
+				)
+			}
+		}
+	}
+
+	c.collectCost(ch, metrics)
+
+	// Model metadata is reported once per distinct model across all days and features
+	for _, model := range collectModelInfo(metrics) {
+		ch <- prometheus.MustNewConstMetric(
+			c.modelInfo,
+			prometheus.GaugeValue,
+			1,
+			model.name, strconv.FormatBool(model.IsCustomModel), model.CustomModelTrainingDate,
+		)
+	}
+}
+
+// exportedDays returns the days to export for the day mode: all of them, or only the latest complete
+// one. Today's data may still change, so it never counts as complete.
+func (c *CopilotCollector) exportedDays(metrics CopilotAPIResponse) CopilotAPIResponse {
+	if c.dayMode != DayModeLatest {
+		return metrics
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	latest := -1
+	for i, metric := range metrics {
+		if metric.Day < today && (latest < 0 || metric.Day > metrics[latest].Day) {
+			latest = i
+		}
+	}
+	if latest < 0 {
+		return nil
+	}
+	return metrics[latest : latest+1]
+}
+
+// dailyMetric creates a gauge for one of the API's days. Like MustNewConstMetric, the day precedes
+// the other label values; the day mode decides whether it becomes a label or the sample timestamp.
+func (c *CopilotCollector) dailyMetric(desc *prometheus.Desc, valueType prometheus.ValueType, value float64, day string, labelValues ...string) prometheus.Metric {
+	switch c.dayMode {
+	case DayModeLabel:
+		return prometheus.MustNewConstMetric(desc, valueType, value, append([]string{day}, labelValues...)...)
+	case DayModeTimestamp:
+		ts, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return prometheus.NewInvalidMetric(desc, fmt.Errorf("invalid day %q: %w", day, err))
+		}
+		return prometheus.NewMetricWithTimestamp(ts, prometheus.MustNewConstMetric(desc, valueType, value, labelValues...))
+	default:
+		return prometheus.MustNewConstMetric(desc, valueType, value, labelValues...)
+	}
+}
+
+// orgLabel returns the value of the org label, which is the enterprise name for enterprise scope
+func (c *CopilotCollector) orgLabel() string {
+	if c.enterprise != "" {
+		return c.enterprise
+	}
+	return c.organization
+}
+
+// target returns the collector's organization, team or enterprise
+func (c *CopilotCollector) target() Target {
+	return Target{Organization: c.organization, Team: c.team, Enterprise: c.enterprise}
+}
+
+// modelInfo identifies a model together with its custom model metadata
+type modelInfo struct {
+	name string
+	CustomModelInfo
+}
+
+// collectModelInfo returns the distinct models reported across all features, sorted by name
+func collectModelInfo(metrics CopilotAPIResponse) []modelInfo {
+	seen := make(map[modelInfo]bool)
+	add := func(name string, info CustomModelInfo) {
+		seen[modelInfo{name: labelOrUnknown(name), CustomModelInfo: info}] = true
+	}
+
+	for _, metric := range metrics {
+		for _, editor := range metric.CopilotIDECodeCompletions.Editors {
+			for _, model := range editor.Models {
+				add(model.Name, model.CustomModelInfo)
+			}
+		}
+		for _, editor := range metric.CopilotIDEChat.Editors {
+			for _, model := range editor.Models {
+				add(model.Name, model.CustomModelInfo)
+			}
+		}
+		for _, model := range metric.CopilotDotcomChat.Models {
+			add(model.Name, model.CustomModelInfo)
+		}
+		for _, repo := range metric.CopilotDotcomPullRequests.Repositories {
+			for _, model := range repo.Models {
+				add(model.Name, model.CustomModelInfo)
+			}
+		}
+	}
+
+	models := make([]modelInfo, 0, len(seen))
+	for model := range seen {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].name != models[j].name {
+			return models[i].name < models[j].name
+		}
+		return models[i].CustomModelTrainingDate < models[j].CustomModelTrainingDate
+	})
+	return models
+}
+
+// Helper function to export the nested editor -> model -> language code completion metrics
+func (c *CopilotCollector) exportIDECodeCompletionsEditor(ch chan<- prometheus.Metric, day string, editor IDECodeCompletionsEditor) {
+	editorName := labelOrUnknown(editor.Name)
+
+	if editor.TotalEngagedUsers > 0 {
+		ch <- c.dailyMetric(
+			c.ideCodeCompletionsEditorEngagedUsers,
+			prometheus.GaugeValue,
+			float64(editor.TotalEngagedUsers),
+			day, editorName,
+		)
+	}
+
+	for _, model := range editor.Models {
+		modelName := labelOrUnknown(model.Name)
+		isCustom := strconv.FormatBool(model.IsCustomModel)
+
+		if model.TotalEngagedUsers > 0 {
+			ch <- c.dailyMetric(
+				c.ideCodeCompletionsModelEngagedUsers,
+				prometheus.GaugeValue,
+				float64(model.TotalEngagedUsers),
+				day, editorName, modelName, isCustom,
+			)
+		}
+
+		for _, lang := range model.Languages {
+			language := labelOrUnknown(lang.Name)
+
+			if lang.TotalEngagedUsers > 0 {
+				ch <- c.dailyMetric(
+					c.ideCodeCompletionsModelLanguageEngagedUsers,
+					prometheus.GaugeValue,
+					float64(lang.TotalEngagedUsers),
+					day, editorName, modelName, isCustom, language,
+				)
+			}
+
+			// Counts are always exported for reported languages so ratios can be computed in PromQL
+			ch <- c.dailyMetric(
+				c.ideCodeCompletionsSuggestions,
+				prometheus.GaugeValue,
+				float64(lang.TotalCodeSuggestions),
+				day, editorName, modelName, isCustom, language,
+			)
+			ch <- c.dailyMetric(
+				c.ideCodeCompletionsAcceptances,
+				prometheus.GaugeValue,
+				float64(lang.TotalCodeAcceptances),
+				day, editorName, modelName, isCustom, language,
+			)
+			ch <- c.dailyMetric(
+				c.ideCodeCompletionsLinesSuggested,
+				prometheus.GaugeValue,
+				float64(lang.TotalCodeLinesSuggested),
+				day, editorName, modelName, isCustom, language,
+			)
+			ch <- c.dailyMetric(
+				c.ideCodeCompletionsLinesAccepted,
+				prometheus.GaugeValue,
+				float64(lang.TotalCodeLinesAccepted),
+				day, editorName, modelName, isCustom, language,
+			)
+
+			acceptanceRate := 0.0
+			if lang.TotalCodeSuggestions > 0 {
+				acceptanceRate = float64(lang.TotalCodeAcceptances) / float64(lang.TotalCodeSuggestions)
 			}
+			ch <- c.dailyMetric(
+				c.ideCodeCompletionsAcceptanceRate,
+				prometheus.GaugeValue,
+				acceptanceRate,
+				day, editorName, modelName, isCustom, language,
+			)
 		}
+	}
+}
 
-		// Dotcom Pull Requests - Models breakdown
-		for _, model := range metric.CopilotDotcomPullRequests.Models {
-			c.exportBreakdown(ch, day, org, model, "model")
+// Helper function to export the nested editor -> model IDE chat metrics
+func (c *CopilotCollector) exportIDEChatEditor(ch chan<- prometheus.Metric, day string, editor IDEChatEditor) {
+	editorName := labelOrUnknown(editor.Name)
+
+	if editor.TotalEngagedUsers > 0 {
+		ch <- c.dailyMetric(
+			c.ideChatEditorEngagedUsers,
+			prometheus.GaugeValue,
+			float64(editor.TotalEngagedUsers),
+			day, editorName,
+		)
+	}
+
+	for _, model := range editor.Models {
+		modelName := labelOrUnknown(model.Name)
+		isCustom := strconv.FormatBool(model.IsCustomModel)
+
+		if model.TotalEngagedUsers > 0 {
+			ch <- c.dailyMetric(
+				c.ideChatModelEngagedUsers,
+				prometheus.GaugeValue,
+				float64(model.TotalEngagedUsers),
+				day, editorName, modelName, isCustom,
+			)
 		}
+
+		// Counts are always exported for reported models so insertion/copy ratios can be computed in PromQL
+		ch <- c.dailyMetric(
+			c.ideChatChats,
+			prometheus.GaugeValue,
+			float64(model.TotalChats),
+			day, editorName, modelName, isCustom,
+		)
+		ch <- c.dailyMetric(
+			c.ideChatInsertionEvents,
+			prometheus.GaugeValue,
+			float64(model.TotalChatInsertionEvents),
+			day, editorName, modelName, isCustom,
+		)
+		ch <- c.dailyMetric(
+			c.ideChatCopyEvents,
+			prometheus.GaugeValue,
+			float64(model.TotalChatCopyEvents),
+			day, editorName, modelName, isCustom,
+		)
+	}
+}
+
+// labelOrUnknown substitutes "unknown" for empty dimension names
+func labelOrUnknown(name string) string {
+	if name == "" {
+		return "unknown"
 	}
+	return name
 }
 
 // Helper function to export breakdown metrics
-func (c *CopilotCollector) exportBreakdown(ch chan<- prometheus.Metric, day, org string, breakdown Breakdown, breakdownType string) {
+func (c *CopilotCollector) exportBreakdown(ch chan<- prometheus.Metric, day string, breakdown Breakdown, breakdownType string) {
 	language := breakdown.Language
 	editor := breakdown.Editor
 	model := breakdown.Model
@@ -565,139 +1514,444 @@ func (c *CopilotCollector) exportBreakdown(ch chan<- prometheus.Metric, day, org
 	}
 
 	if breakdown.SuggestionsCount > 0 {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.breakdownSuggestions,
 			prometheus.GaugeValue,
 			float64(breakdown.SuggestionsCount),
-			day, org, language, editor, model,
+			day, language, editor, model,
 		)
 	}
 	if breakdown.AcceptancesCount > 0 {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.breakdownAcceptances,
 			prometheus.GaugeValue,
 			float64(breakdown.AcceptancesCount),
-			day, org, language, editor, model,
+			day, language, editor, model,
 		)
 	}
 	if breakdown.LinesSuggested > 0 {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.breakdownLinesSuggested,
 			prometheus.GaugeValue,
 			float64(breakdown.LinesSuggested),
-			day, org, language, editor, model,
+			day, language, editor, model,
 		)
 	}
 	if breakdown.LinesAccepted > 0 {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.breakdownLinesAccepted,
 			prometheus.GaugeValue,
 			float64(breakdown.LinesAccepted),
-			day, org, language, editor, model,
+			day, language, editor, model,
 		)
 	}
 	if breakdown.ActiveUsers > 0 {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.breakdownActiveUsers,
 			prometheus.GaugeValue,
 			float64(breakdown.ActiveUsers),
-			day, org, language, editor, model,
+			day, language, editor, model,
 		)
 	}
 	if breakdown.ChatAcceptances > 0 {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.breakdownChatAcceptances,
 			prometheus.GaugeValue,
 			float64(breakdown.ChatAcceptances),
-			day, org, language, editor, model,
+			day, language, editor, model,
 		)
 	}
 	if breakdown.ChatTurns > 0 {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.breakdownChatTurns,
 			prometheus.GaugeValue,
 			float64(breakdown.ChatTurns),
-			day, org, language, editor, model,
+			day, language, editor, model,
 		)
 	}
 	if breakdown.ActiveChatUsers > 0 {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- c.dailyMetric(
 			c.breakdownActiveChatUsers,
 			prometheus.GaugeValue,
 			float64(breakdown.ActiveChatUsers),
-			day, org, language, editor, model,
+			day, language, editor, model,
 		)
 	}
 }
 
-func (c *CopilotCollector) fetchMetrics() (CopilotAPIResponse, error) {
-	var apiURL string
+// parseTimeEnv reads an optional date (2006-01-02) or RFC 3339 timestamp from the environment
+func parseTimeEnv(name string) (time.Time, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date (YYYY-MM-DD) or RFC 3339 timestamp: %w", name, err)
+	}
+	return t, nil
+}
 
-	if c.enterprise != "" {
-		apiURL = fmt.Sprintf("https://api.github.com/enterprises/%s/copilot/metrics", c.enterprise)
-	} else if c.team != "" {
-		apiURL = fmt.Sprintf("https://api.github.com/orgs/%s/team/%s/copilot/metrics", c.organization, c.team)
-	} else {
-		apiURL = fmt.Sprintf("https://api.github.com/orgs/%s/copilot/metrics", c.organization)
+// timeRangeFromEnv reads the optional METRICS_SINCE and METRICS_UNTIL bounds, rejecting a reversed window
+// that GitHub would answer with 422 on every fetch
+func timeRangeFromEnv() (since, until time.Time, err error) {
+	if since, err = parseTimeEnv("METRICS_SINCE"); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if until, err = parseTimeEnv("METRICS_UNTIL"); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !since.IsZero() && !until.IsZero() && since.After(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("METRICS_SINCE (%s) must not be after METRICS_UNTIL (%s)", os.Getenv("METRICS_SINCE"), os.Getenv("METRICS_UNTIL"))
+	}
+	return since, until, nil
+}
+
+// appTokenSourceFromEnv configures GitHub App installation authentication when GITHUB_APP_ID is set
+func appTokenSourceFromEnv(baseURL string) (tokenSource, error) {
+	appID := os.Getenv("GITHUB_APP_ID")
+	if appID == "" {
+		return nil, nil
+	}
+
+	installationID := os.Getenv("GITHUB_APP_INSTALLATION_ID")
+	keyFile := os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE")
+	if installationID == "" || keyFile == "" {
+		return nil, fmt.Errorf("GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PRIVATE_KEY_FILE are required with GITHUB_APP_ID")
+	}
+	if err := validateAppID("GITHUB_APP_ID", appID); err != nil {
+		return nil, err
+	}
+	if err := validateAppID("GITHUB_APP_INSTALLATION_ID", installationID); err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequest("GET", apiURL, nil)
+	keyPEM, err := os.ReadFile(keyFile)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return nil, fmt.Errorf("error reading GITHUB_APP_PRIVATE_KEY_FILE: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.githubToken)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	return newAppTokenSource(baseURL, appID, installationID, keyPEM)
+}
+
+// config is the exporter configuration read from the environment
+type config struct {
+	targets      []Target
+	targetTokens []string
+	githubToken  string
+	appTokens    tokenSource
+
+	// Collector options shared by all targets
+	opts []CollectorOption
+
+	// Optional persistent history, shared by all targets
+	store *Store
+
+	// Serve /api/v1/seats, with logins hashed under seatsHashKey when it is set
+	seatsAPI     bool
+	seatsHashKey string
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	port                  string
+	pollInterval          time.Duration
+	teamDiscoveryInterval time.Duration
+}
+
+// configFromEnv reads and validates the configuration, exiting on invalid values
+func configFromEnv() *config {
+	baseURL := defaultGitHubAPIURL
+	if v := os.Getenv("GITHUB_API_URL"); v != "" {
+		var err error
+		baseURL, err = normalizeBaseURL(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	appTokens, err := appTokenSourceFromEnv(baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
+		log.Fatal(err)
+	}
+
+	var targets []Target
+	if path := os.Getenv("TARGETS_FILE"); path != "" {
+		targets, err = loadTargets(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		target := Target{
+			Organization:  os.Getenv("GITHUB_ORG"),
+			Team:          os.Getenv("GITHUB_TEAM"),
+			Enterprise:    os.Getenv("GITHUB_ENTERPRISE"),
+			DiscoverTeams: os.Getenv("GITHUB_DISCOVER_TEAMS") == "true",
+			TeamInclude:   os.Getenv("GITHUB_TEAM_INCLUDE"),
+			TeamExclude:   os.Getenv("GITHUB_TEAM_EXCLUDE"),
+		}
+		if target.Organization == "" && target.Enterprise == "" && (target.Team != "" || target.DiscoverTeams) {
+			log.Fatal("GITHUB_TEAM and GITHUB_DISCOVER_TEAMS require GITHUB_ORG")
+		}
+		if target.DiscoverTeams && (target.Organization == "" || target.Team != "") {
+			log.Fatal("GITHUB_DISCOVER_TEAMS requires GITHUB_ORG and cannot be combined with GITHUB_TEAM")
+		}
+		if _, err := newTeamFilter(target.TeamInclude, target.TeamExclude); err != nil {
+			log.Fatal(err)
+		}
+		// Without GITHUB_ORG or GITHUB_ENTERPRISE, targets are only scraped through /probe
+		if target.Organization != "" || target.Enterprise != "" {
+			targets = []Target{target}
+		}
+	}
+
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	if len(targets) == 0 && githubToken == "" && appTokens == nil {
+		log.Fatal("GITHUB_TOKEN or GITHUB_APP_ID environment variable is required to serve /probe without GITHUB_ORG or GITHUB_ENTERPRISE")
+	}
+	targetTokens := make([]string, len(targets))
+	for i, target := range targets {
+		targetTokens[i], err = target.resolveToken()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if targetTokens[i] == "" && githubToken == "" && appTokens == nil {
+			log.Fatalf("GITHUB_TOKEN or GITHUB_APP_ID environment variable is required for target %s", target)
+		}
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = defaultPort
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	opts := []CollectorOption{WithBaseURL(baseURL)}
+
+	if v := os.Getenv("METRICS_LOOKBACK_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 || days > maxLookbackDays {
+			log.Fatalf("METRICS_LOOKBACK_DAYS must be a number between 1 and %d", maxLookbackDays)
+		}
+		opts = append(opts, WithLookbackDays(days))
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	since, until, err := timeRangeFromEnv()
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+		log.Fatal(err)
+	}
+	if !since.IsZero() || !until.IsZero() {
+		opts = append(opts, WithTimeRange(since, until))
+	}
+
+	if v := os.Getenv("METRICS_PER_PAGE"); v != "" {
+		perPage, err := strconv.Atoi(v)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			log.Fatalf("METRICS_PER_PAGE must be a number between 1 and %d", maxPerPage)
+		}
+		opts = append(opts, WithPerPage(perPage))
+	}
+
+	if v := os.Getenv("DAY_MODE"); v != "" {
+		mode := DayMode(v)
+		if mode != DayModeLabel && mode != DayModeTimestamp && mode != DayModeLatest {
+			log.Fatal("DAY_MODE must be label, timestamp or latest")
+		}
+		opts = append(opts, WithDayMode(mode))
 	}
 
-	var metrics CopilotAPIResponse
-	if err := json.Unmarshal(body, &metrics); err != nil {
-		return nil, fmt.Errorf("error unmarshaling response: %w", err)
+	pollInterval := defaultPollInterval
+	if v := os.Getenv("POLL_INTERVAL"); v != "" {
+		pollInterval, err = time.ParseDuration(v)
+		if err != nil || pollInterval < 0 {
+			log.Fatal("POLL_INTERVAL must be a non-negative duration such as 30m or 1h")
+		}
+	}
+	opts = append(opts, WithPollInterval(pollInterval))
+
+	if v := os.Getenv("HTTP_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			log.Fatal("HTTP_TIMEOUT must be a positive duration such as 10s or 1m")
+		}
+		opts = append(opts, WithHTTPTimeout(timeout))
+	}
+
+	maxRetries := defaultMaxRetries
+	if v := os.Getenv("MAX_RETRIES"); v != "" {
+		maxRetries, err = strconv.Atoi(v)
+		if err != nil || maxRetries < 0 {
+			log.Fatal("MAX_RETRIES must be a non-negative number")
+		}
+	}
+	opts = append(opts, WithRetries(maxRetries))
+
+	if v := os.Getenv("MAX_RATE_LIMIT_WAIT"); v != "" {
+		wait, err := time.ParseDuration(v)
+		if err != nil || wait < 0 {
+			log.Fatal("MAX_RATE_LIMIT_WAIT must be a non-negative duration such as 1m or 5m")
+		}
+		opts = append(opts, WithMaxRateLimitWait(wait))
 	}
 
-	return metrics, nil
+	maxStaleness := defaultMaxStaleness
+	if v := os.Getenv("MAX_STALENESS"); v != "" {
+		maxStaleness, err = time.ParseDuration(v)
+		if err != nil || maxStaleness < 0 {
+			log.Fatal("MAX_STALENESS must be a non-negative duration such as 12h or 48h")
+		}
+	}
+	opts = append(opts, WithMaxStaleness(maxStaleness))
+
+	fetchSeats := os.Getenv("FETCH_SEATS") == "true"
+	opts = append(opts, WithSeats(fetchSeats))
+
+	seatPrices := make(map[string]float64)
+	for plan, name := range map[string]string{"business": "SEAT_PRICE_BUSINESS", "enterprise": "SEAT_PRICE_ENTERPRISE"} {
+		if v := os.Getenv(name); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil || price < 0 {
+				log.Fatalf("%s must be a non-negative number", name)
+			}
+			seatPrices[plan] = price
+		}
+	}
+	if len(seatPrices) > 0 {
+		if !fetchSeats {
+			log.Fatal("SEAT_PRICE_BUSINESS and SEAT_PRICE_ENTERPRISE require FETCH_SEATS=true")
+		}
+		opts = append(opts, WithSeatPrices(seatPrices))
+	}
+
+	seatsAPI := os.Getenv("SEATS_API") == "true"
+	if seatsAPI && !fetchSeats {
+		log.Fatal("SEATS_API requires FETCH_SEATS=true")
+	}
+
+	var store *Store
+	if path := os.Getenv("STORE_PATH"); path != "" {
+		retentionDays := 0
+		if v := os.Getenv("STORE_RETENTION_DAYS"); v != "" {
+			retentionDays, err = strconv.Atoi(v)
+			if err != nil || retentionDays < 0 {
+				log.Fatal("STORE_RETENTION_DAYS must be a non-negative number")
+			}
+		}
+		store, err = OpenStore(path, retentionDays)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, WithStore(store))
+	}
+
+	teamDiscoveryInterval := defaultTeamDiscoveryInterval
+	if v := os.Getenv("TEAM_DISCOVERY_INTERVAL"); v != "" {
+		teamDiscoveryInterval, err = time.ParseDuration(v)
+		if err != nil || teamDiscoveryInterval <= 0 {
+			log.Fatal("TEAM_DISCOVERY_INTERVAL must be a positive duration such as 1h or 24h")
+		}
+	}
+
+	return &config{
+		targets:               targets,
+		targetTokens:          targetTokens,
+		githubToken:           githubToken,
+		appTokens:             appTokens,
+		opts:                  opts,
+		store:                 store,
+		seatsAPI:              seatsAPI,
+		seatsHashKey:          os.Getenv("SEATS_API_HASH_KEY"),
+		port:                  port,
+		pollInterval:          pollInterval,
+		teamDiscoveryInterval: teamDiscoveryInterval,
+	}
 }
 
-func main() {
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken == "" {
-		log.Fatal("GITHUB_TOKEN environment variable is required")
+// newCollector builds the collector for target with token, or with the exporter-wide credentials
+// when token is empty. extra options are applied after the shared ones.
+func (cfg *config) newCollector(target Target, token string, extra ...CollectorOption) *CopilotCollector {
+	opts := append([]CollectorOption{}, cfg.opts...)
+	if token == "" {
+		token = cfg.githubToken
+		if cfg.appTokens != nil {
+			opts = append(opts, WithTokenSource(cfg.appTokens))
+		}
 	}
+	opts = append(opts, extra...)
+	return NewCopilotCollector(token, target.Organization, target.Team, target.Enterprise, opts...)
+}
 
-	organization := os.Getenv("GITHUB_ORG")
-	team := os.Getenv("GITHUB_TEAM")
-	enterprise := os.Getenv("GITHUB_ENTERPRISE")
+// newProbeCollector builds the collector of a probe. Probes fetch on every request and, since any
+// organization can be probed and nothing reads their history back, are not persisted in the store.
+func (cfg *config) newProbeCollector(target Target) *CopilotCollector {
+	return cfg.newCollector(target, "", WithPollInterval(0), WithStore(nil))
+}
 
-	if organization == "" && enterprise == "" {
-		log.Fatal("Either GITHUB_ORG or GITHUB_ENTERPRISE environment variable is required")
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		runBackfill(os.Args[2:])
+		return
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	cfg := configFromEnv()
+
+	// One collector per target, each with its own snapshot so a failing target doesn't affect the others
+	labels := targetLabels(cfg.targets)
+	var collectors []*CopilotCollector
+	var discoverers []*TeamDiscoverer
+	for i, target := range cfg.targets {
+		collector := cfg.newCollector(target, cfg.targetTokens[i])
+		collectors = append(collectors, collector)
+		if err := prometheus.WrapRegistererWith(labels[i], prometheus.DefaultRegisterer).Register(collector); err != nil {
+			log.Fatalf("Error registering target %s: %v", target, err)
+		}
+
+		if cfg.pollInterval > 0 {
+			go collector.Poll(context.Background())
+		}
+
+		if target.DiscoverTeams {
+			filter, _ := newTeamFilter(target.TeamInclude, target.TeamExclude)
+			token := cfg.targetTokens[i]
+			newCollector := func(team string) *CopilotCollector {
+				return cfg.newCollector(Target{Organization: target.Organization, Team: team}, token)
+			}
+			discoverer := NewTeamDiscoverer(collector, filter, cfg.teamDiscoveryInterval, prometheus.DefaultRegisterer, labels[i], newCollector)
+			discoverers = append(discoverers, discoverer)
+			go discoverer.Run(context.Background())
+		}
 	}
 
-	collector := NewCopilotCollector(githubToken, organization, team, enterprise)
-	prometheus.MustRegister(collector)
+	otlpExporter, err := newOTLPExporterFromEnv(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if otlpExporter != nil {
+		// Push on the refresh schedule; new data only arrives that often
+		pushInterval := cfg.pollInterval
+		if pushInterval == 0 {
+			pushInterval = defaultPollInterval
+		}
+		go NewOTLPPusher(prometheus.DefaultGatherer, otlpExporter, pushInterval).Run(context.Background())
+		log.Printf("Pushing metrics over OTLP every %s", pushInterval)
+	}
 
 	http.Handle(metricsEndpoint, promhttp.Handler())
+	allCollectors := func() []*CopilotCollector {
+		all := append([]*CopilotCollector{}, collectors...)
+		for _, discoverer := range discoverers {
+			all = append(all, discoverer.Collectors()...)
+		}
+		return all
+	}
+	http.Handle(apiMetricsEndpoint, newMetricsAPIHandler(allCollectors))
+	if cfg.seatsAPI {
+		// Per-user data, so only served when explicitly enabled
+		http.Handle(apiSeatsEndpoint, newSeatsAPIHandler(allCollectors, cfg.seatsHashKey))
+	}
+	if cfg.githubToken != "" || cfg.appTokens != nil {
+		// Probes fetch on every request, using the exporter-wide credentials
+		http.Handle(probeEndpoint, newProbeHandler(cfg.newProbeCollector))
+	}
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprintf(w, `<html>
@@ -705,19 +1959,24 @@ func main() {
 <body>
 <h1>GitHub Copilot Metrics Exporter</h1>
 <p><a href="%s">Metrics</a></p>
+<p><a href="%s">JSON API</a></p>
 </body>
-</html>`, metricsEndpoint)
+</html>`, metricsEndpoint, apiMetricsEndpoint)
 	})
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, "OK")
 	})
 
-	log.Printf("Starting GitHub Copilot Metrics Exporter on port %s", port)
-	log.Printf("Metrics will be fetched fresh from GitHub API on each scrape")
-	log.Printf("Metrics available at http://localhost:%s%s", port, metricsEndpoint)
+	log.Printf("Starting GitHub Copilot Metrics Exporter on port %s for %d target(s)", cfg.port, len(cfg.targets))
+	if cfg.pollInterval > 0 {
+		log.Printf("Metrics will be refreshed from GitHub API every %s", cfg.pollInterval)
+	} else {
+		log.Printf("Metrics will be fetched fresh from GitHub API on each scrape")
+	}
+	log.Printf("Metrics available at http://localhost:%s%s", cfg.port, metricsEndpoint)
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	if err := http.ListenAndServe(":"+cfg.port, nil); err != nil {
 		log.Fatal(err)
 	}
 }
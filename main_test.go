@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -82,7 +84,7 @@ func TestNewCopilotCollectorWithEnterprise(t *testing.T) {
 
 func TestCopilotCollector_Describe(t *testing.T) {
 	collector := NewCopilotCollector("test-token", "test-org", "", "")
-	ch := make(chan *prometheus.Desc, 30)
+	ch := make(chan *prometheus.Desc, 100)
 
 	collector.Describe(ch)
 	close(ch)
@@ -92,9 +94,41 @@ func TestCopilotCollector_Describe(t *testing.T) {
 		count++
 	}
 
-	// Should have 22 metrics
-	if count != 22 {
-		t.Errorf("Expected 22 metric descriptions, got %d", count)
+	// Should have 64 metrics
+	if count != 64 {
+		t.Errorf("Expected 64 metric descriptions, got %d", count)
+	}
+}
+
+func TestCopilotCollector_Collect_ScopeLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		org        string
+		team       string
+		enterprise string
+		labels     string
+	}{
+		{name: "organization", org: "test-org", labels: `org="test-org",scope="org",team=""`},
+		{name: "team", org: "test-org", team: "platform", labels: `org="test-org",scope="team",team="platform"`},
+		{name: "enterprise", enterprise: "test-enterprise", labels: `org="test-enterprise",scope="enterprise",team=""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewCopilotCollector("test-token", tt.org, tt.team, tt.enterprise)
+			collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+				return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 10}}, nil
+			}
+
+			expected := `
+# HELP github_copilot_active_users_total Total number of active Copilot users
+# TYPE github_copilot_active_users_total gauge
+github_copilot_active_users_total{day="2024-01-01",` + tt.labels + `} 10
+`
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "github_copilot_active_users_total"); err != nil {
+				t.Errorf("Unexpected metrics: %v", err)
+			}
+		})
 	}
 }
 
@@ -124,13 +158,16 @@ func TestCopilotCollector_FetchMetrics_Organization(t *testing.T) {
 		if r.Header.Get("X-GitHub-Api-Version") != "2022-11-28" {
 			t.Errorf("Expected X-GitHub-Api-Version header '2022-11-28'")
 		}
+		if r.URL.Path != "/orgs/test-org/copilot/metrics" {
+			t.Errorf("Expected path /orgs/test-org/copilot/metrics, got %s", r.URL.Path)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(mockResponse)
 	}))
 	defer server.Close()
 
-	collector := NewCopilotCollector("test-token", "test-org", "", "")
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
 
 	// We can verify the collector structure is correct
 	if collector.organization != "test-org" {
@@ -139,6 +176,14 @@ func TestCopilotCollector_FetchMetrics_Organization(t *testing.T) {
 	if collector.githubToken != "test-token" {
 		t.Errorf("Expected token 'test-token', got '%s'", collector.githubToken)
 	}
+
+	metrics, err := collector.fetchMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].TotalSuggestionsCount != 100 {
+		t.Errorf("Unexpected metrics: %+v", metrics)
+	}
 }
 
 func TestCopilotCollector_FetchMetrics_Team(t *testing.T) {
@@ -163,11 +208,18 @@ func TestCopilotCollector_FetchMetrics_Team(t *testing.T) {
 	}))
 	defer server.Close()
 
-	collector := NewCopilotCollector("test-token", "test-org", "test-team", "")
-	// Test that team URL is constructed correctly (we can't actually test without mocking the full HTTP client)
+	collector := NewCopilotCollector("test-token", "test-org", "test-team", "", WithBaseURL(server.URL))
 	if collector.team != "test-team" {
 		t.Errorf("Expected team 'test-team', got '%s'", collector.team)
 	}
+
+	metrics, err := collector.fetchMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].TotalActiveUsers != 5 {
+		t.Errorf("Unexpected metrics: %+v", metrics)
+	}
 }
 
 func TestCopilotCollector_FetchMetrics_Enterprise(t *testing.T) {
@@ -202,23 +254,28 @@ func TestCopilotCollector_Collect(t *testing.T) {
 			"total_engaged_users": 10,
 			"languages": [
 				{
-					"language": "python",
-					"suggestions_count": 50,
-					"acceptances_count": 40
+					"name": "python",
+					"total_engaged_users": 10
 				}
 			],
 			"editors": [
 				{
-					"editor": "vscode",
-					"suggestions_count": 50,
-					"acceptances_count": 40
-				}
-			],
-			"models": [
-				{
-					"model": "gpt-4",
-					"suggestions_count": 50,
-					"acceptances_count": 40
+					"name": "vscode",
+					"total_engaged_users": 10,
+					"models": [
+						{
+							"name": "default",
+							"total_engaged_users": 10,
+							"languages": [
+								{
+									"name": "python",
+									"total_engaged_users": 10,
+									"total_code_suggestions": 50,
+									"total_code_acceptances": 40
+								}
+							]
+						}
+					]
 				}
 			]
 		},
@@ -226,16 +283,17 @@ func TestCopilotCollector_Collect(t *testing.T) {
 			"total_engaged_users": 5,
 			"editors": [
 				{
-					"editor": "vscode",
-					"chat_acceptances": 20,
-					"chat_turns": 30
-				}
-			],
-			"models": [
-				{
-					"model": "gpt-4",
-					"chat_acceptances": 20,
-					"chat_turns": 30
+					"name": "vscode",
+					"total_engaged_users": 5,
+					"models": [
+						{
+							"name": "default",
+							"total_engaged_users": 5,
+							"total_chats": 30,
+							"total_chat_insertion_events": 20,
+							"total_chat_copy_events": 4
+						}
+					]
 				}
 			]
 		},
@@ -243,8 +301,9 @@ func TestCopilotCollector_Collect(t *testing.T) {
 			"total_engaged_users": 3,
 			"models": [
 				{
-					"model": "gpt-4",
-					"chat_turns": 15
+					"name": "default",
+					"total_engaged_users": 3,
+					"total_chats": 15
 				}
 			]
 		},
@@ -256,8 +315,8 @@ func TestCopilotCollector_Collect(t *testing.T) {
 					"total_engaged_users": 2,
 					"models": [
 						{
-							"model": "gpt-4",
-							"suggestions_count": 10
+							"name": "gpt-4",
+							"total_pr_summaries_created": 10
 						}
 					]
 				}
@@ -309,7 +368,7 @@ func TestCopilotCollector_ExportBreakdown(t *testing.T) {
 		ActiveChatUsers:  3,
 	}
 
-	collector.exportBreakdown(ch, "2024-01-01", "test-org", breakdown, "language")
+	collector.exportBreakdown(ch, "2024-01-01", breakdown, "language")
 
 	close(ch)
 
@@ -333,7 +392,7 @@ func TestCopilotCollector_ExportBreakdown_EmptyFields(t *testing.T) {
 		// All other fields are 0
 	}
 
-	collector.exportBreakdown(ch, "2024-01-01", "test-org", breakdown, "language")
+	collector.exportBreakdown(ch, "2024-01-01", breakdown, "language")
 
 	close(ch)
 
@@ -357,7 +416,7 @@ func TestCopilotCollector_ExportBreakdown_UnknownType(t *testing.T) {
 	}
 
 	// Should handle missing language/editor/model gracefully
-	collector.exportBreakdown(ch, "2024-01-01", "test-org", breakdown, "language")
+	collector.exportBreakdown(ch, "2024-01-01", breakdown, "language")
 
 	close(ch)
 
@@ -446,8 +505,8 @@ func TestCopilotAPIResponseWithNestedStructures(t *testing.T) {
 			"total_engaged_users": 10,
 			"languages": [
 				{
-					"language": "python",
-					"suggestions_count": 50
+					"name": "python",
+					"total_engaged_users": 8
 				}
 			]
 		},
@@ -476,8 +535,8 @@ func TestCopilotAPIResponseWithNestedStructures(t *testing.T) {
 		t.Fatalf("Expected 1 language, got %d", len(response[0].CopilotIDECodeCompletions.Languages))
 	}
 
-	if response[0].CopilotIDECodeCompletions.Languages[0].Language != "python" {
-		t.Errorf("Expected language 'python', got '%s'", response[0].CopilotIDECodeCompletions.Languages[0].Language)
+	if response[0].CopilotIDECodeCompletions.Languages[0].Name != "python" {
+		t.Errorf("Expected language 'python', got '%s'", response[0].CopilotIDECodeCompletions.Languages[0].Name)
 	}
 
 	if len(response[0].CopilotDotcomPullRequests.Repositories) != 1 {
@@ -524,15 +583,14 @@ func TestCopilotCollector_FetchMetrics_Success(t *testing.T) {
 	defer server.Close()
 
 	// Test with organization
-	collector := &CopilotCollector{
-		githubToken:  "test-token",
-		organization: "test-org",
-	}
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
 
-	// We can't directly test fetchMetrics without modifying production code,
-	// but we verify the structure is correct
-	if collector.organization != "test-org" {
-		t.Errorf("Expected organization 'test-org', got '%s'", collector.organization)
+	metrics, err := collector.fetchMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].TotalAcceptancesCount != 80 {
+		t.Errorf("Unexpected metrics: %+v", metrics)
 	}
 }
 
@@ -544,14 +602,14 @@ func TestCopilotCollector_FetchMetrics_ErrorHandling(t *testing.T) {
 	}))
 	defer server.Close()
 
-	collector := &CopilotCollector{
-		githubToken:  "test-token",
-		organization: "test-org",
-	}
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
 
-	// Verify error handling setup
-	if collector.githubToken == "" {
-		t.Error("Expected non-empty token")
+	_, err := collector.fetchMetrics()
+	if err == nil {
+		t.Fatal("Expected error for 500 response")
+	}
+	if !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("Expected status 500 in error, got %v", err)
 	}
 }
 
@@ -603,7 +661,7 @@ func TestCopilotCollector_ExportBreakdown_AllFields(t *testing.T) {
 	// Test with all breakdown types
 	breakdownTypes := []string{"language", "editor", "model"}
 	for _, breakdownType := range breakdownTypes {
-		collector.exportBreakdown(ch, "2024-01-01", "test-org", breakdown, breakdownType)
+		collector.exportBreakdown(ch, "2024-01-01", breakdown, breakdownType)
 	}
 
 	close(ch)
@@ -631,7 +689,7 @@ func TestCopilotCollector_ExportBreakdown_PartialFields(t *testing.T) {
 		// Other fields are zero
 	}
 
-	collector.exportBreakdown(ch, "2024-01-01", "test-org", breakdown, "language")
+	collector.exportBreakdown(ch, "2024-01-01", breakdown, "language")
 
 	close(ch)
 
@@ -657,7 +715,7 @@ func TestCopilotCollector_ExportBreakdown_EditorType(t *testing.T) {
 		ActiveUsers:      3,
 	}
 
-	collector.exportBreakdown(ch, "2024-01-01", "test-org", breakdown, "editor")
+	collector.exportBreakdown(ch, "2024-01-01", breakdown, "editor")
 
 	close(ch)
 
@@ -682,7 +740,7 @@ func TestCopilotCollector_ExportBreakdown_ModelType(t *testing.T) {
 		ChatTurns:        15,
 	}
 
-	collector.exportBreakdown(ch, "2024-01-01", "test-org", breakdown, "model")
+	collector.exportBreakdown(ch, "2024-01-01", breakdown, "model")
 
 	close(ch)
 
@@ -754,28 +812,42 @@ func TestCopilotAPIResponse_AllNestedStructures(t *testing.T) {
 			"total_engaged_users": 50,
 			"languages": [
 				{
-					"language": "python",
-					"suggestions_count": 250,
-					"acceptances_count": 200
+					"name": "python",
+					"total_engaged_users": 30
 				},
 				{
-					"language": "go",
-					"suggestions_count": 150,
-					"acceptances_count": 120
+					"name": "go",
+					"total_engaged_users": 20
 				}
 			],
 			"editors": [
 				{
-					"editor": "vscode",
-					"suggestions_count": 400,
-					"acceptances_count": 320
-				}
-			],
-			"models": [
-				{
-					"model": "gpt-4",
-					"suggestions_count": 600,
-					"acceptances_count": 480
+					"name": "vscode",
+					"total_engaged_users": 40,
+					"models": [
+						{
+							"name": "default",
+							"total_engaged_users": 40,
+							"languages": [
+								{
+									"name": "python",
+									"total_engaged_users": 25,
+									"total_code_suggestions": 250,
+									"total_code_acceptances": 200,
+									"total_code_lines_suggested": 1250,
+									"total_code_lines_accepted": 1000
+								},
+								{
+									"name": "go",
+									"total_engaged_users": 15,
+									"total_code_suggestions": 150,
+									"total_code_acceptances": 120,
+									"total_code_lines_suggested": 750,
+									"total_code_lines_accepted": 600
+								}
+							]
+						}
+					]
 				}
 			]
 		},
@@ -783,16 +855,17 @@ func TestCopilotAPIResponse_AllNestedStructures(t *testing.T) {
 			"total_engaged_users": 25,
 			"editors": [
 				{
-					"editor": "vscode",
-					"chat_acceptances": 150,
-					"chat_turns": 200
-				}
-			],
-			"models": [
-				{
-					"model": "gpt-4",
-					"chat_acceptances": 150,
-					"chat_turns": 200
+					"name": "vscode",
+					"total_engaged_users": 25,
+					"models": [
+						{
+							"name": "default",
+							"total_engaged_users": 25,
+							"total_chats": 200,
+							"total_chat_insertion_events": 150,
+							"total_chat_copy_events": 30
+						}
+					]
 				}
 			]
 		},
@@ -800,12 +873,14 @@ func TestCopilotAPIResponse_AllNestedStructures(t *testing.T) {
 			"total_engaged_users": 15,
 			"models": [
 				{
-					"model": "gpt-4",
-					"chat_turns": 50
+					"name": "default",
+					"total_engaged_users": 10,
+					"total_chats": 50
 				},
 				{
-					"model": "gpt-3.5",
-					"chat_turns": 30
+					"name": "custom-model",
+					"total_engaged_users": 5,
+					"total_chats": 30
 				}
 			]
 		},
@@ -817,8 +892,8 @@ func TestCopilotAPIResponse_AllNestedStructures(t *testing.T) {
 					"total_engaged_users": 5,
 					"models": [
 						{
-							"model": "gpt-4",
-							"suggestions_count": 20
+							"name": "gpt-4",
+							"total_pr_summaries_created": 20
 						}
 					]
 				},
@@ -827,8 +902,8 @@ func TestCopilotAPIResponse_AllNestedStructures(t *testing.T) {
 					"total_engaged_users": 5,
 					"models": [
 						{
-							"model": "gpt-4",
-							"suggestions_count": 15
+							"name": "gpt-4",
+							"total_pr_summaries_created": 15
 						}
 					]
 				}
@@ -868,8 +943,25 @@ func TestCopilotAPIResponse_AllNestedStructures(t *testing.T) {
 	if len(response[0].CopilotIDECodeCompletions.Editors) != 1 {
 		t.Errorf("Expected 1 editor, got %d", len(response[0].CopilotIDECodeCompletions.Editors))
 	}
-	if len(response[0].CopilotIDECodeCompletions.Models) != 1 {
-		t.Errorf("Expected 1 model, got %d", len(response[0].CopilotIDECodeCompletions.Models))
+	editor := response[0].CopilotIDECodeCompletions.Editors[0]
+	if editor.Name != "vscode" {
+		t.Errorf("Expected editor 'vscode', got '%s'", editor.Name)
+	}
+	if len(editor.Models) != 1 {
+		t.Fatalf("Expected 1 model, got %d", len(editor.Models))
+	}
+	if editor.Models[0].Name != "default" {
+		t.Errorf("Expected model 'default', got '%s'", editor.Models[0].Name)
+	}
+	if len(editor.Models[0].Languages) != 2 {
+		t.Fatalf("Expected 2 model languages, got %d", len(editor.Models[0].Languages))
+	}
+	goLang := editor.Models[0].Languages[1]
+	if goLang.Name != "go" || goLang.TotalCodeSuggestions != 150 || goLang.TotalCodeAcceptances != 120 {
+		t.Errorf("Unexpected go language entry: %+v", goLang)
+	}
+	if goLang.TotalCodeLinesSuggested != 750 || goLang.TotalCodeLinesAccepted != 600 {
+		t.Errorf("Unexpected go line counts: %+v", goLang)
 	}
 
 	// Verify IDE Chat
@@ -879,8 +971,13 @@ func TestCopilotAPIResponse_AllNestedStructures(t *testing.T) {
 	if len(response[0].CopilotIDEChat.Editors) != 1 {
 		t.Errorf("Expected 1 chat editor, got %d", len(response[0].CopilotIDEChat.Editors))
 	}
-	if len(response[0].CopilotIDEChat.Models) != 1 {
-		t.Errorf("Expected 1 chat model, got %d", len(response[0].CopilotIDEChat.Models))
+	chatEditor := response[0].CopilotIDEChat.Editors[0]
+	if len(chatEditor.Models) != 1 {
+		t.Fatalf("Expected 1 chat model, got %d", len(chatEditor.Models))
+	}
+	chatModel := chatEditor.Models[0]
+	if chatModel.TotalChats != 200 || chatModel.TotalChatInsertionEvents != 150 || chatModel.TotalChatCopyEvents != 30 {
+		t.Errorf("Unexpected chat model entry: %+v", chatModel)
 	}
 
 	// Verify Dotcom Chat
@@ -901,8 +998,8 @@ func TestCopilotAPIResponse_AllNestedStructures(t *testing.T) {
 	if response[0].CopilotDotcomPullRequests.Repositories[0].Name != "repo1" {
 		t.Errorf("Expected repository name 'repo1', got '%s'", response[0].CopilotDotcomPullRequests.Repositories[0].Name)
 	}
-	if len(response[0].CopilotDotcomPullRequests.Models) != 1 {
-		t.Errorf("Expected 1 PR model, got %d", len(response[0].CopilotDotcomPullRequests.Models))
+	if response[0].CopilotDotcomPullRequests.Repositories[1].Models[0].TotalPRSummariesCreated != 15 {
+		t.Errorf("Expected 15 PR summaries for repo2, got %d", response[0].CopilotDotcomPullRequests.Repositories[1].Models[0].TotalPRSummariesCreated)
 	}
 }
 
@@ -953,48 +1050,54 @@ func TestCopilotCollector_Collect_Integration(t *testing.T) {
 				"total_engaged_users": 50,
 				"languages": [
 					{
-						"language": "python",
-						"suggestions_count": 250,
-						"acceptances_count": 200,
-						"lines_suggested": 1250,
-						"lines_accepted": 1000,
-						"active_users": 15
+						"name": "python",
+						"total_engaged_users": 15
 					},
 					{
-						"language": "javascript",
-						"suggestions_count": 200,
-						"acceptances_count": 160,
-						"lines_suggested": 1000,
-						"lines_accepted": 800,
-						"active_users": 12
+						"name": "javascript",
+						"total_engaged_users": 12
 					}
 				],
 				"editors": [
 					{
-						"editor": "vscode",
-						"suggestions_count": 400,
-						"acceptances_count": 320,
-						"lines_suggested": 2000,
-						"lines_accepted": 1600,
-						"active_users": 28
+						"name": "vscode",
+						"total_engaged_users": 28,
+						"models": [
+							{
+								"name": "default",
+								"total_engaged_users": 28,
+								"languages": [
+									{
+										"name": "python",
+										"total_engaged_users": 15,
+										"total_code_suggestions": 250,
+										"total_code_acceptances": 200,
+										"total_code_lines_suggested": 1250,
+										"total_code_lines_accepted": 1000
+									}
+								]
+							}
+						]
 					},
 					{
-						"editor": "intellij",
-						"suggestions_count": 100,
-						"acceptances_count": 80,
-						"lines_suggested": 500,
-						"lines_accepted": 400,
-						"active_users": 8
-					}
-				],
-				"models": [
-					{
-						"model": "gpt-4",
-						"suggestions_count": 600,
-						"acceptances_count": 480,
-						"lines_suggested": 3000,
-						"lines_accepted": 2400,
-						"active_users": 40
+						"name": "intellij",
+						"total_engaged_users": 8,
+						"models": [
+							{
+								"name": "default",
+								"total_engaged_users": 8,
+								"languages": [
+									{
+										"name": "javascript",
+										"total_engaged_users": 8,
+										"total_code_suggestions": 100,
+										"total_code_acceptances": 80,
+										"total_code_lines_suggested": 500,
+										"total_code_lines_accepted": 400
+									}
+								]
+							}
+						]
 					}
 				]
 			},
@@ -1002,24 +1105,30 @@ func TestCopilotCollector_Collect_Integration(t *testing.T) {
 				"total_engaged_users": 25,
 				"editors": [
 					{
-						"editor": "vscode",
-						"chat_acceptances": 150,
-						"chat_turns": 200,
-						"active_chat_users": 18
+						"name": "vscode",
+						"total_engaged_users": 18,
+						"models": [
+							{
+								"name": "default",
+								"total_engaged_users": 18,
+								"total_chats": 200,
+								"total_chat_insertion_events": 150,
+								"total_chat_copy_events": 30
+							}
+						]
 					},
 					{
-						"editor": "intellij",
-						"chat_acceptances": 50,
-						"chat_turns": 100,
-						"active_chat_users": 7
-					}
-				],
-				"models": [
-					{
-						"model": "gpt-4",
-						"chat_acceptances": 180,
-						"chat_turns": 280,
-						"active_chat_users": 22
+						"name": "intellij",
+						"total_engaged_users": 7,
+						"models": [
+							{
+								"name": "default",
+								"total_engaged_users": 7,
+								"total_chats": 100,
+								"total_chat_insertion_events": 50,
+								"total_chat_copy_events": 10
+							}
+						]
 					}
 				]
 			},
@@ -1027,16 +1136,14 @@ func TestCopilotCollector_Collect_Integration(t *testing.T) {
 				"total_engaged_users": 15,
 				"models": [
 					{
-						"model": "gpt-4",
-						"chat_turns": 80,
-						"chat_acceptances": 60,
-						"active_chat_users": 12
+						"name": "default",
+						"total_engaged_users": 12,
+						"total_chats": 80
 					},
 					{
-						"model": "gpt-3.5",
-						"chat_turns": 40,
-						"chat_acceptances": 30,
-						"active_chat_users": 8
+						"name": "custom-model",
+						"total_engaged_users": 8,
+						"total_chats": 40
 					}
 				]
 			},
@@ -1048,10 +1155,9 @@ func TestCopilotCollector_Collect_Integration(t *testing.T) {
 						"total_engaged_users": 6,
 						"models": [
 							{
-								"model": "gpt-4",
-								"suggestions_count": 30,
-								"acceptances_count": 24,
-								"active_users": 6
+								"name": "gpt-4",
+								"total_pr_summaries_created": 30,
+								"total_engaged_users": 6
 							}
 						]
 					},
@@ -1060,10 +1166,9 @@ func TestCopilotCollector_Collect_Integration(t *testing.T) {
 						"total_engaged_users": 4,
 						"models": [
 							{
-								"model": "gpt-4",
-								"suggestions_count": 20,
-								"acceptances_count": 16,
-								"active_users": 4
+								"name": "gpt-4",
+								"total_pr_summaries_created": 20,
+								"total_engaged_users": 4
 							}
 						]
 					}
@@ -1148,7 +1253,7 @@ func TestCopilotCollector_Describe_AllMetrics(t *testing.T) {
 	collector := NewCopilotCollector("test-token", "test-org", "", "")
 
 	descriptors := make(map[string]bool)
-	ch := make(chan *prometheus.Desc, 30)
+	ch := make(chan *prometheus.Desc, 100)
 	go func() {
 		collector.Describe(ch)
 		close(ch)
@@ -1158,9 +1263,9 @@ func TestCopilotCollector_Describe_AllMetrics(t *testing.T) {
 		descriptors[desc.String()] = true
 	}
 
-	// Should have exactly 22 unique descriptors
-	if len(descriptors) != 22 {
-		t.Errorf("Expected 22 unique metric descriptors, got %d", len(descriptors))
+	// Should have exactly 64 unique descriptors
+	if len(descriptors) != 64 {
+		t.Errorf("Expected 64 unique metric descriptors, got %d", len(descriptors))
 	}
 }
 
@@ -1289,10 +1394,16 @@ func TestCopilotCollector_FetchMetrics_SuccessfulResponse(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Test would require injecting server URL - verify collector setup
-	collector := NewCopilotCollector("test-token", "test-org", "", "")
-	if collector.organization != "test-org" {
-		t.Errorf("Expected org test-org, got %s", collector.organization)
+	// Collect end to end against the fake API, without the test fetcher hook
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	expected := `
+# HELP github_copilot_suggestions_total Total number of Copilot suggestions
+# TYPE github_copilot_suggestions_total gauge
+github_copilot_suggestions_total{day="2024-01-01",org="test-org",scope="org",team=""} 100
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "github_copilot_suggestions_total"); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
 	}
 }
 
@@ -1316,9 +1427,9 @@ func TestCopilotCollector_Collect_WithError(t *testing.T) {
 		count++
 	}
 
-	// Should return 0 metrics on error
-	if count != 0 {
-		t.Errorf("Expected 0 metrics on error, got %d", count)
+	// Should only report the failed scrape on error
+	if count != 1 {
+		t.Errorf("Expected 1 metric (scrape success) on error, got %d", count)
 	}
 }
 
@@ -1354,9 +1465,9 @@ func TestCopilotCollector_Collect_ZeroAcceptances(t *testing.T) {
 		count++
 	}
 
-	// Should collect 9 top-level metrics even with zeros
-	if count != 9 {
-		t.Errorf("Expected 9 metrics (including zero values), got %d", count)
+	// Should collect 9 top-level metrics even with zeros, plus 3 exporter metrics
+	if count != 12 {
+		t.Errorf("Expected 12 metrics (including zero values), got %d", count)
 	}
 }
 
@@ -1442,8 +1553,297 @@ func TestCopilotCollector_Collect_AllFeatures(t *testing.T) {
 		count++
 	}
 
-	// 9 top-level + 4 feature-specific = 13
-	if count != 13 {
-		t.Errorf("Expected 13 metrics, got %d", count)
+	// 3 exporter + 9 top-level + 4 feature-specific = 16
+	if count != 16 {
+		t.Errorf("Expected 16 metrics, got %d", count)
+	}
+}
+
+// Test nested editor -> model -> language code completion metrics
+func TestCopilotCollector_Collect_IDECodeCompletionsNested(t *testing.T) {
+	mockData := `[{
+		"day": "2024-01-01",
+		"copilot_ide_code_completions": {
+			"total_engaged_users": 12,
+			"languages": [
+				{"name": "go", "total_engaged_users": 12}
+			],
+			"editors": [
+				{
+					"name": "JetBrains",
+					"total_engaged_users": 12,
+					"models": [
+						{
+							"name": "default",
+							"total_engaged_users": 12,
+							"languages": [
+								{
+									"name": "go",
+									"total_engaged_users": 12,
+									"total_code_suggestions": 200,
+									"total_code_acceptances": 50,
+									"total_code_lines_suggested": 400,
+									"total_code_lines_accepted": 80
+								}
+							]
+						}
+					]
+				}
+			]
+		}
+	}]`
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "")
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		var response CopilotAPIResponse
+		err := json.Unmarshal([]byte(mockData), &response)
+		return response, err
+	}
+
+	expected := `
+# HELP github_copilot_ide_code_completions_acceptance_rate IDE code completion acceptance rate (acceptances/suggestions) by editor, model and language
+# TYPE github_copilot_ide_code_completions_acceptance_rate gauge
+github_copilot_ide_code_completions_acceptance_rate{day="2024-01-01",editor="JetBrains",is_custom_model="false",language="go",model="default",org="test-org",scope="org",team=""} 0.25
+# HELP github_copilot_ide_code_completions_lines_accepted_total IDE code completion lines accepted by editor, model and language
+# TYPE github_copilot_ide_code_completions_lines_accepted_total gauge
+github_copilot_ide_code_completions_lines_accepted_total{day="2024-01-01",editor="JetBrains",is_custom_model="false",language="go",model="default",org="test-org",scope="org",team=""} 80
+# HELP github_copilot_ide_code_completions_model_engaged_users Engaged users for IDE code completions by editor and model
+# TYPE github_copilot_ide_code_completions_model_engaged_users gauge
+github_copilot_ide_code_completions_model_engaged_users{day="2024-01-01",editor="JetBrains",is_custom_model="false",model="default",org="test-org",scope="org",team=""} 12
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_ide_code_completions_acceptance_rate",
+		"github_copilot_ide_code_completions_lines_accepted_total",
+		"github_copilot_ide_code_completions_model_engaged_users",
+	)
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+
+	// 3 exporter + 9 top-level + 1 engaged + 1 language + 1 editor + 1 model + 1 model language + 5 counts/rate + 1 model info = 23
+	if count := testutil.CollectAndCount(collector); count != 23 {
+		t.Errorf("Expected 23 metrics, got %d", count)
+	}
+}
+
+// Test nested editor -> model IDE chat metrics
+func TestCopilotCollector_Collect_IDEChatNested(t *testing.T) {
+	mockData := `[{
+		"day": "2024-01-01",
+		"copilot_ide_chat": {
+			"total_engaged_users": 9,
+			"editors": [
+				{
+					"name": "vscode",
+					"total_engaged_users": 9,
+					"models": [
+						{
+							"name": "default",
+							"total_engaged_users": 9,
+							"total_chats": 40,
+							"total_chat_insertion_events": 12,
+							"total_chat_copy_events": 6
+						}
+					]
+				}
+			]
+		}
+	}]`
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "")
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		var response CopilotAPIResponse
+		err := json.Unmarshal([]byte(mockData), &response)
+		return response, err
+	}
+
+	expected := `
+# HELP github_copilot_ide_chat_chats_total IDE chats by editor and model
+# TYPE github_copilot_ide_chat_chats_total gauge
+github_copilot_ide_chat_chats_total{day="2024-01-01",editor="vscode",is_custom_model="false",model="default",org="test-org",scope="org",team=""} 40
+# HELP github_copilot_ide_chat_copy_events_total IDE chat copy events by editor and model
+# TYPE github_copilot_ide_chat_copy_events_total gauge
+github_copilot_ide_chat_copy_events_total{day="2024-01-01",editor="vscode",is_custom_model="false",model="default",org="test-org",scope="org",team=""} 6
+# HELP github_copilot_ide_chat_insertion_events_total IDE chat code insertion events by editor and model
+# TYPE github_copilot_ide_chat_insertion_events_total gauge
+github_copilot_ide_chat_insertion_events_total{day="2024-01-01",editor="vscode",is_custom_model="false",model="default",org="test-org",scope="org",team=""} 12
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_ide_chat_chats_total",
+		"github_copilot_ide_chat_copy_events_total",
+		"github_copilot_ide_chat_insertion_events_total",
+	)
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+// Test PR summaries are exported per repository and model
+func TestCopilotCollector_Collect_PRSummaries(t *testing.T) {
+	mockData := `[{
+		"day": "2024-01-01",
+		"copilot_dotcom_pull_requests": {
+			"total_engaged_users": 3,
+			"repositories": [
+				{
+					"name": "org/api",
+					"total_engaged_users": 2,
+					"models": [
+						{"name": "default", "total_engaged_users": 2, "total_pr_summaries_created": 7}
+					]
+				},
+				{
+					"name": "org/web",
+					"total_engaged_users": 1,
+					"models": [
+						{"name": "default", "total_engaged_users": 1, "total_pr_summaries_created": 3}
+					]
+				}
+			]
+		}
+	}]`
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "")
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		var response CopilotAPIResponse
+		err := json.Unmarshal([]byte(mockData), &response)
+		return response, err
+	}
+
+	expected := `
+# HELP github_copilot_dotcom_pr_summaries_created Pull request summaries created by Copilot by repository and model
+# TYPE github_copilot_dotcom_pr_summaries_created gauge
+github_copilot_dotcom_pr_summaries_created{day="2024-01-01",is_custom_model="false",model="default",org="test-org",repository="org/api",scope="org",team=""} 7
+github_copilot_dotcom_pr_summaries_created{day="2024-01-01",is_custom_model="false",model="default",org="test-org",repository="org/web",scope="org",team=""} 3
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "github_copilot_dotcom_pr_summaries_created")
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+// Test custom model metadata is exported as labels and an info metric
+func TestCopilotCollector_Collect_CustomModels(t *testing.T) {
+	mockData := `[{
+		"day": "2024-01-01",
+		"copilot_ide_code_completions": {
+			"editors": [
+				{
+					"name": "vscode",
+					"models": [
+						{
+							"name": "default",
+							"is_custom_model": false,
+							"languages": [
+								{"name": "go", "total_code_suggestions": 10, "total_code_acceptances": 5}
+							]
+						},
+						{
+							"name": "acme-model",
+							"is_custom_model": true,
+							"custom_model_training_date": "2024-05-01",
+							"languages": [
+								{"name": "go", "total_code_suggestions": 20, "total_code_acceptances": 15}
+							]
+						}
+					]
+				}
+			]
+		},
+		"copilot_dotcom_chat": {
+			"models": [
+				{
+					"name": "acme-model",
+					"is_custom_model": true,
+					"custom_model_training_date": "2024-05-01",
+					"total_engaged_users": 2,
+					"total_chats": 9
+				}
+			]
+		}
+	}]`
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "")
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		var response CopilotAPIResponse
+		err := json.Unmarshal([]byte(mockData), &response)
+		return response, err
+	}
+
+	expected := `
+# HELP github_copilot_dotcom_chat_chats_total Dotcom chats by model
+# TYPE github_copilot_dotcom_chat_chats_total gauge
+github_copilot_dotcom_chat_chats_total{day="2024-01-01",is_custom_model="true",model="acme-model",org="test-org",scope="org",team=""} 9
+# HELP github_copilot_ide_code_completions_acceptances_total IDE code completion acceptances by editor, model and language
+# TYPE github_copilot_ide_code_completions_acceptances_total gauge
+github_copilot_ide_code_completions_acceptances_total{day="2024-01-01",editor="vscode",is_custom_model="false",language="go",model="default",org="test-org",scope="org",team=""} 5
+github_copilot_ide_code_completions_acceptances_total{day="2024-01-01",editor="vscode",is_custom_model="true",language="go",model="acme-model",org="test-org",scope="org",team=""} 15
+# HELP github_copilot_model_info Copilot model metadata, including whether it is a custom model and its training date
+# TYPE github_copilot_model_info gauge
+github_copilot_model_info{custom_model_training_date="",is_custom_model="false",model="default",org="test-org",scope="org",team=""} 1
+github_copilot_model_info{custom_model_training_date="2024-05-01",is_custom_model="true",model="acme-model",org="test-org",scope="org",team=""} 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_dotcom_chat_chats_total",
+		"github_copilot_ide_code_completions_acceptances_total",
+		"github_copilot_model_info",
+	)
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+func TestCopilotCollector_Collect_DayModeTimestamp(t *testing.T) {
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithDayMode(DayModeTimestamp))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return CopilotAPIResponse{
+			{Day: "2024-01-01", TotalActiveUsers: 10},
+			{Day: "2024-01-02", TotalActiveUsers: 12},
+		}, nil
+	}
+
+	// Samples are timestamped at each day's midnight UTC, in milliseconds
+	expected := `
+# HELP github_copilot_active_users_total Total number of active Copilot users
+# TYPE github_copilot_active_users_total gauge
+github_copilot_active_users_total{org="test-org",scope="org",team=""} 10 1704067200000
+github_copilot_active_users_total{org="test-org",scope="org",team=""} 12 1704153600000
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "github_copilot_active_users_total"); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+func TestCopilotCollector_Collect_DayModeLatest(t *testing.T) {
+	today := time.Now().UTC().Format(time.DateOnly)
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithDayMode(DayModeLatest))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return CopilotAPIResponse{
+			{Day: "2024-01-02", TotalActiveUsers: 12},
+			{Day: "2024-01-01", TotalActiveUsers: 10},
+			{Day: today, TotalActiveUsers: 3},
+		}, nil
+	}
+
+	// Today is still incomplete, so the latest complete day is 2024-01-02
+	expected := `
+# HELP github_copilot_active_users_total Total number of active Copilot users
+# TYPE github_copilot_active_users_total gauge
+github_copilot_active_users_total{org="test-org",scope="org",team=""} 12
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "github_copilot_active_users_total"); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+func TestCopilotCollector_Collect_DayModeLatest_NoCompleteDay(t *testing.T) {
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithDayMode(DayModeLatest))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return CopilotAPIResponse{{Day: time.Now().UTC().Format(time.DateOnly), TotalActiveUsers: 3}}, nil
+	}
+
+	if count := testutil.CollectAndCount(collector, "github_copilot_active_users_total"); count != 0 {
+		t.Errorf("Expected no daily metrics, got %d", count)
 	}
 }
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+const (
+	otlpScopeName     = "github.com/hemuvemula/github-copilot-metrics-exporter"
+	otlpPushTimeout   = 30 * time.Second
+	otlpMetricsPrefix = "github_copilot_"
+)
+
+// otlpUnits maps metric name fragments to UCUM units, most specific first
+var otlpUnits = []struct {
+	fragment string
+	unit     string
+}{
+	{"_timestamp_seconds", "s"},
+	{"_age_seconds", "s"},
+	{"_acceptance_rate", "1"},
+	{"scrape_success", "1"},
+	{"_info", "1"},
+	{"rate_limit", "{request}"},
+	{"_cost", "{currency}"},
+	{"seats", "{seat}"},
+	{"engaged_users", "{user}"},
+	{"active_users", "{user}"},
+	{"active_chat_users", "{user}"},
+	{"lines", "{line}"},
+	{"suggestions", "{suggestion}"},
+	{"acceptances", "{acceptance}"},
+	{"chat_turns", "{turn}"},
+	{"chats", "{chat}"},
+	{"events", "{event}"},
+	{"pr_summaries", "{summary}"},
+}
+
+// otlpUnit returns the unit of a metric family by name
+func otlpUnit(name string) string {
+	for _, u := range otlpUnits {
+		if strings.Contains(name, u.fragment) {
+			return u.unit
+		}
+	}
+	return ""
+}
+
+// newOTLPExporterFromEnv creates an OTLP metric exporter when OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_METRICS_ENDPOINT is set. The protocol is taken from OTEL_EXPORTER_OTLP_METRICS_PROTOCOL
+// or OTEL_EXPORTER_OTLP_PROTOCOL (grpc or http/protobuf, default http/protobuf); endpoint, headers and TLS
+// settings are read from the standard OTEL_EXPORTER_OTLP_* variables by the exporter itself.
+func newOTLPExporterFromEnv(ctx context.Context) (sdkmetric.Exporter, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") == "" {
+		return nil, nil
+	}
+
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_METRICS_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+
+	switch protocol {
+	case "grpc":
+		return otlpmetricgrpc.New(ctx)
+	case "", "http/protobuf":
+		return otlpmetrichttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q, use grpc or http/protobuf", protocol)
+	}
+}
+
+// OTLPPusher periodically pushes the Copilot metrics of a gatherer to an OTLP exporter, with one resource
+// per target carrying its org, team or enterprise as resource attributes
+type OTLPPusher struct {
+	gatherer prometheus.Gatherer
+	exporter sdkmetric.Exporter
+	interval time.Duration
+}
+
+// NewOTLPPusher creates a pusher that exports the github_copilot_ metrics of gatherer every interval
+func NewOTLPPusher(gatherer prometheus.Gatherer, exporter sdkmetric.Exporter, interval time.Duration) *OTLPPusher {
+	return &OTLPPusher{gatherer: gatherer, exporter: exporter, interval: interval}
+}
+
+// Run pushes every interval until ctx is cancelled, then shuts the exporter down. The first push waits
+// at most a minute, giving the pollers time for their initial fetch.
+func (p *OTLPPusher) Run(ctx context.Context) {
+	timer := time.NewTimer(min(p.interval, time.Minute))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), otlpPushTimeout)
+			defer cancel()
+			if err := p.exporter.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Error shutting down OTLP exporter: %v", err)
+			}
+			return
+		case <-timer.C:
+		}
+
+		pushCtx, cancel := context.WithTimeout(ctx, otlpPushTimeout)
+		if err := p.Push(pushCtx); err != nil {
+			log.Printf("Error pushing OTLP metrics: %v", err)
+		}
+		cancel()
+		timer.Reset(p.interval)
+	}
+}
+
+// Push gathers the current metrics and exports one ResourceMetrics per target
+func (p *OTLPPusher) Push(ctx context.Context) error {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("error gathering metrics: %w", err)
+	}
+
+	var errs []string
+	for _, rm := range resourceMetrics(families, time.Now()) {
+		if err := p.exporter.Export(ctx, rm); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error exporting: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// otlpTarget identifies the target of a sample by its org, team and scope labels
+type otlpTarget struct {
+	org, team, scope string
+}
+
+// resource returns the target's resource: the default SDK resource plus github.* attributes
+func (t otlpTarget) resource() *resource.Resource {
+	attrs := []attribute.KeyValue{attribute.String("github.copilot.scope", t.scope)}
+	if t.scope == "enterprise" {
+		attrs = append(attrs, attribute.String("github.enterprise", t.org))
+	} else {
+		attrs = append(attrs, attribute.String("github.org", t.org))
+	}
+	if t.team != "" {
+		attrs = append(attrs, attribute.String("github.team", t.team))
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		// Only conflicting schema URLs fail, which a schemaless resource cannot cause
+		return resource.NewSchemaless(attrs...)
+	}
+	return res
+}
+
+// resourceMetrics converts gathered github_copilot_ gauge families to OTLP gauges grouped by target.
+// The org, team and scope labels become resource attributes and the remaining labels data point
+// attributes. Samples without a timestamp are stamped with now.
+func resourceMetrics(families []*dto.MetricFamily, now time.Time) []*metricdata.ResourceMetrics {
+	metricsByTarget := make(map[otlpTarget][]metricdata.Metrics)
+
+	for _, mf := range families {
+		if !strings.HasPrefix(mf.GetName(), otlpMetricsPrefix) || mf.GetType() != dto.MetricType_GAUGE {
+			continue
+		}
+
+		points := make(map[otlpTarget][]metricdata.DataPoint[float64])
+		for _, m := range mf.GetMetric() {
+			var target otlpTarget
+			var attrs []attribute.KeyValue
+			for _, label := range m.GetLabel() {
+				switch label.GetName() {
+				case "org":
+					target.org = label.GetValue()
+				case "team":
+					target.team = label.GetValue()
+				case "scope":
+					target.scope = label.GetValue()
+				default:
+					attrs = append(attrs, attribute.String(label.GetName(), label.GetValue()))
+				}
+			}
+
+			ts := now
+			if m.TimestampMs != nil {
+				ts = time.UnixMilli(m.GetTimestampMs())
+			}
+			points[target] = append(points[target], metricdata.DataPoint[float64]{
+				Attributes: attribute.NewSet(attrs...),
+				Time:       ts,
+				Value:      m.GetGauge().GetValue(),
+			})
+		}
+
+		for target, dataPoints := range points {
+			metricsByTarget[target] = append(metricsByTarget[target], metricdata.Metrics{
+				Name:        mf.GetName(),
+				Description: mf.GetHelp(),
+				Unit:        otlpUnit(mf.GetName()),
+				Data:        metricdata.Gauge[float64]{DataPoints: dataPoints},
+			})
+		}
+	}
+
+	targets := make([]otlpTarget, 0, len(metricsByTarget))
+	for target := range metricsByTarget {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		a, b := targets[i], targets[j]
+		if a.org != b.org {
+			return a.org < b.org
+		}
+		if a.team != b.team {
+			return a.team < b.team
+		}
+		return a.scope < b.scope
+	})
+
+	result := make([]*metricdata.ResourceMetrics, len(targets))
+	for i, target := range targets {
+		result[i] = &metricdata.ResourceMetrics{
+			Resource: target.resource(),
+			ScopeMetrics: []metricdata.ScopeMetrics{{
+				Scope:   instrumentation.Scope{Name: otlpScopeName},
+				Metrics: metricsByTarget[target],
+			}},
+		}
+	}
+	return result
+}
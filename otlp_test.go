@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recordingExporter is an OTLP exporter that keeps what it is asked to export
+type recordingExporter struct {
+	mu       sync.Mutex
+	exported []*metricdata.ResourceMetrics
+}
+
+func (e *recordingExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *recordingExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *recordingExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.exported = append(e.exported, rm)
+	return nil
+}
+
+func (e *recordingExporter) ForceFlush(context.Context) error { return nil }
+func (e *recordingExporter) Shutdown(context.Context) error   { return nil }
+
+func newOTLPTestRegistry(t *testing.T, opts ...CollectorOption) *prometheus.Registry {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+
+	org := NewCopilotCollector("test-token", "test-org", "", "", opts...)
+	org.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 10}}, nil
+	}
+	team := NewCopilotCollector("test-token", "test-org", "platform", "", opts...)
+	team.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 4}}, nil
+	}
+	reg.MustRegister(org, team)
+	return reg
+}
+
+// findMetric returns the named metric of a resource
+func findMetric(rm *metricdata.ResourceMetrics, name string) (metricdata.Metrics, bool) {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m, true
+			}
+		}
+	}
+	return metricdata.Metrics{}, false
+}
+
+func TestOTLPPusher_Push(t *testing.T) {
+	exporter := &recordingExporter{}
+	pusher := NewOTLPPusher(newOTLPTestRegistry(t), exporter, time.Hour)
+
+	if err := pusher.Push(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(exporter.exported) != 2 {
+		t.Fatalf("Expected one resource per target, got %d", len(exporter.exported))
+	}
+
+	// Targets are sorted, so the org resource comes before its team
+	tests := []struct {
+		team  string
+		scope string
+		users float64
+	}{
+		{team: "", scope: "org", users: 10},
+		{team: "platform", scope: "team", users: 4},
+	}
+	for i, tt := range tests {
+		rm := exporter.exported[i]
+		attrs := rm.Resource.Set()
+		if v, _ := attrs.Value("github.org"); v.AsString() != "test-org" {
+			t.Errorf("Resource %d: expected github.org test-org, got %q", i, v.AsString())
+		}
+		if v, _ := attrs.Value("github.copilot.scope"); v.AsString() != tt.scope {
+			t.Errorf("Resource %d: expected scope %s, got %q", i, tt.scope, v.AsString())
+		}
+		if v, ok := attrs.Value("github.team"); (tt.team != "") != ok || v.AsString() != tt.team {
+			t.Errorf("Resource %d: expected team %q, got %q", i, tt.team, v.AsString())
+		}
+
+		m, ok := findMetric(rm, "github_copilot_active_users_total")
+		if !ok {
+			t.Fatalf("Resource %d: expected github_copilot_active_users_total", i)
+		}
+		if m.Unit != "{user}" {
+			t.Errorf("Resource %d: expected unit {user}, got %q", i, m.Unit)
+		}
+		points := m.Data.(metricdata.Gauge[float64]).DataPoints
+		if len(points) != 1 || points[0].Value != tt.users {
+			t.Fatalf("Resource %d: expected one data point of %v, got %+v", i, tt.users, points)
+		}
+		if v, _ := points[0].Attributes.Value(attribute.Key("day")); v.AsString() != "2024-01-01" {
+			t.Errorf("Resource %d: expected day attribute, got %v", i, points[0].Attributes)
+		}
+		if _, ok := points[0].Attributes.Value(attribute.Key("org")); ok {
+			t.Errorf("Resource %d: org should be a resource attribute only", i)
+		}
+	}
+}
+
+func TestResourceMetrics_DayTimestamps(t *testing.T) {
+	families, err := newOTLPTestRegistry(t, WithDayMode(DayModeTimestamp)).Gather()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	rms := resourceMetrics(families, now)
+
+	m, _ := findMetric(rms[0], "github_copilot_active_users_total")
+	if got := m.Data.(metricdata.Gauge[float64]).DataPoints[0].Time; !got.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the day as data point time, got %v", got)
+	}
+
+	m, _ = findMetric(rms[0], "github_copilot_scrape_success")
+	if got := m.Data.(metricdata.Gauge[float64]).DataPoints[0].Time; !got.Equal(now) {
+		t.Errorf("Expected samples without timestamp at now, got %v", got)
+	}
+	if m.Unit != "1" {
+		t.Errorf("Expected unit 1, got %q", m.Unit)
+	}
+}
+
+func TestOTLPUnit(t *testing.T) {
+	tests := map[string]string{
+		"github_copilot_last_success_timestamp_seconds":            "s",
+		"github_copilot_rate_limit_reset_timestamp_seconds":        "s",
+		"github_copilot_rate_limit_remaining":                      "{request}",
+		"github_copilot_ide_code_completions_acceptance_rate":      "1",
+		"github_copilot_ide_code_completions_lines_accepted_total": "{line}",
+		"github_copilot_ide_chat_insertion_events_total":           "{event}",
+		"github_copilot_dotcom_chat_chats_total":                   "{chat}",
+		"github_copilot_chat_turns_total":                          "{turn}",
+		"github_copilot_dotcom_pr_summaries_created":               "{summary}",
+	}
+	for name, expected := range tests {
+		if got := otlpUnit(name); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestNewOTLPExporterFromEnv(t *testing.T) {
+	if exporter, err := newOTLPExporterFromEnv(context.Background()); exporter != nil || err != nil {
+		t.Errorf("Expected no exporter without an endpoint, got %v (%v)", exporter, err)
+	}
+
+	var mu sync.Mutex
+	var paths []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+
+	exporter, err := newOTLPExporterFromEnv(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer exporter.Shutdown(context.Background())
+
+	if err := NewOTLPPusher(newOTLPTestRegistry(t), exporter, time.Hour).Push(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 || paths[0] != "/v1/metrics" {
+		t.Errorf("Expected two exports to /v1/metrics, got %v", paths)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+	if _, err := newOTLPExporterFromEnv(context.Background()); err == nil {
+		t.Error("Expected error for unsupported protocol")
+	}
+}
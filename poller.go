@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Poll refreshes the metrics snapshot immediately and then every poll interval until ctx is cancelled
+func (c *CopilotCollector) Poll(ctx context.Context) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.refresh(); err != nil {
+			log.Printf("Error refreshing metrics for %s: %v", c.target(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh fetches metrics and replaces the snapshot on success, keeping the previous one on error
+func (c *CopilotCollector) refresh() error {
+	if c.seatsSupported() {
+		// Seats are optional; their errors are logged and reported by seats_scrape_success only
+		if err := c.refreshSeats(); err != nil {
+			log.Printf("Error fetching seats for %s: %v", c.target(), err)
+		}
+	}
+
+	var metrics CopilotAPIResponse
+	var err error
+
+	if c.testMetricsFetcher != nil {
+		// Use test fetcher for testing
+		metrics, err = c.testMetricsFetcher()
+	} else {
+		// Use real API in production
+		metrics, err = c.fetchMetrics()
+	}
+
+	if err == nil && c.store != nil {
+		c.persist(metrics)
+		if c.serveHistory {
+			metrics = c.storedHistory(metrics)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refreshErr = err
+	if err != nil {
+		return err
+	}
+	c.snapshot = metrics
+	c.snapshotTime = time.Now()
+
+	return nil
+}
+
+// persist upserts freshly fetched metrics into the store. Store errors are logged only.
+func (c *CopilotCollector) persist(metrics CopilotAPIResponse) {
+	if err := c.store.Upsert(c.target(), metrics); err != nil {
+		log.Printf("Error storing metrics for %s: %v", c.target(), err)
+	}
+}
+
+// storedHistory returns the stored history of the collector's target, which includes days GitHub no longer
+// returns. Without a store, or if it cannot be read, metrics are returned instead.
+func (c *CopilotCollector) storedHistory(metrics CopilotAPIResponse) CopilotAPIResponse {
+	if c.store == nil {
+		return metrics
+	}
+	stored, err := c.store.Load(c.target())
+	if err != nil {
+		log.Printf("Error loading stored metrics for %s: %v", c.target(), err)
+		return metrics
+	}
+	return stored
+}
+
+// currentSnapshot returns the last successfully fetched metrics, when they were fetched and the most recent fetch error
+func (c *CopilotCollector) currentSnapshot() (CopilotAPIResponse, time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snapshot, c.snapshotTime, c.refreshErr
+}
+
+// isStale reports whether data fetched at updated is too old to be served under MAX_STALENESS
+func (c *CopilotCollector) isStale(updated time.Time) bool {
+	return c.maxStaleness > 0 && time.Since(updated) > c.maxStaleness
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCopilotCollector_Poll_ServesSnapshot(t *testing.T) {
+	var fetches atomic.Int32
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithPollInterval(time.Hour))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		fetches.Add(1)
+		return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 7}}, nil
+	}
+
+	// Only scrape success before the first poll
+	if count := testutil.CollectAndCount(collector); count != 1 {
+		t.Errorf("Expected 1 metric before first poll, got %d", count)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		collector.Poll(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for fetches.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if fetches.Load() != 1 {
+		t.Fatalf("Expected 1 fetch from the poller, got %d", fetches.Load())
+	}
+
+	// Scrapes serve the snapshot without fetching again: 3 exporter + 9 top-level
+	if count := testutil.CollectAndCount(collector); count != 12 {
+		t.Errorf("Expected 12 metrics from snapshot, got %d", count)
+	}
+	if count := testutil.CollectAndCount(collector, "github_copilot_snapshot_age_seconds"); count != 1 {
+		t.Errorf("Expected snapshot age metric, got %d", count)
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Expected scrapes not to fetch, got %d fetches", fetches.Load())
+	}
+}
+
+func TestCopilotCollector_Refresh_KeepsSnapshotOnError(t *testing.T) {
+	fail := false
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithPollInterval(time.Hour))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		if fail {
+			return nil, fmt.Errorf("simulated API error")
+		}
+		return CopilotAPIResponse{{Day: "2024-01-01"}}, nil
+	}
+
+	if err := collector.refresh(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, updated, _ := collector.currentSnapshot()
+
+	fail = true
+	if err := collector.refresh(); err == nil {
+		t.Fatal("Expected error from failing fetch")
+	}
+
+	metrics, stillUpdated, err := collector.currentSnapshot()
+	if len(metrics) != 1 || !stillUpdated.Equal(updated) {
+		t.Errorf("Expected previous snapshot to be kept, got %d days updated at %s", len(metrics), stillUpdated)
+	}
+	if err == nil {
+		t.Error("Expected the fetch error to be recorded")
+	}
+}
+
+func TestCopilotCollector_Collect_ServesLastGoodData(t *testing.T) {
+	fail := false
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithMaxStaleness(time.Hour))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		if fail {
+			return nil, fmt.Errorf("simulated API error")
+		}
+		return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 7}}, nil
+	}
+
+	if count := testutil.CollectAndCount(collector, "github_copilot_active_users_total"); count != 1 {
+		t.Fatalf("Expected active users from successful fetch, got %d", count)
+	}
+
+	fail = true
+
+	expected := `
+# HELP github_copilot_active_users_total Total number of active Copilot users
+# TYPE github_copilot_active_users_total gauge
+github_copilot_active_users_total{day="2024-01-01",org="test-org",scope="org",team=""} 7
+# HELP github_copilot_scrape_success Whether the most recent fetch from the GitHub API succeeded (1) or failed (0)
+# TYPE github_copilot_scrape_success gauge
+github_copilot_scrape_success{org="test-org",scope="org",team=""} 0
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_active_users_total",
+		"github_copilot_scrape_success",
+	)
+	if err != nil {
+		t.Errorf("Expected last good data with failed scrape: %v", err)
+	}
+	if count := testutil.CollectAndCount(collector, "github_copilot_last_success_timestamp_seconds"); count != 1 {
+		t.Errorf("Expected last success timestamp, got %d", count)
+	}
+}
+
+func TestCopilotCollector_Collect_DropsDataBeyondStaleness(t *testing.T) {
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithPollInterval(time.Hour), WithMaxStaleness(time.Hour))
+
+	collector.snapshot = CopilotAPIResponse{{Day: "2024-01-01"}}
+	collector.snapshotTime = time.Now().Add(-2 * time.Hour)
+	collector.refreshErr = fmt.Errorf("simulated API error")
+
+	// Only scrape success, last success timestamp and snapshot age remain
+	if count := testutil.CollectAndCount(collector); count != 3 {
+		t.Errorf("Expected 3 exporter metrics for stale snapshot, got %d", count)
+	}
+	if count := testutil.CollectAndCount(collector, "github_copilot_suggestions_total"); count != 0 {
+		t.Errorf("Expected stale data to be dropped, got %d series", count)
+	}
+}
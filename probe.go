@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// newProbeHandler serves the metrics of the target named by the org, team and enterprise query
+// parameters, like blackbox_exporter. target is accepted as an alias for org so Prometheus can
+// set it through __param_target. Every request builds its own collector and registry.
+func newProbeHandler(newCollector func(Target) *CopilotCollector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		target := Target{
+			Organization: query.Get("org"),
+			Team:         query.Get("team"),
+			Enterprise:   query.Get("enterprise"),
+		}
+		if alias := query.Get("target"); alias != "" {
+			if target.Organization != "" {
+				http.Error(w, "org and target are mutually exclusive", http.StatusBadRequest)
+				return
+			}
+			target.Organization = alias
+		}
+		if err := target.validate(); err != nil {
+			http.Error(w, fmt.Sprintf("invalid probe target: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(newCollector(target))
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
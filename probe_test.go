@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func probe(t *testing.T, handler http.Handler, query string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", probeEndpoint+"?"+query, nil))
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestProbeHandler(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/test-org/copilot/metrics":
+			fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 10}]`)
+		case "/orgs/test-org/team/platform/copilot/metrics":
+			fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 4}]`)
+		case "/enterprises/test-enterprise/copilot/metrics":
+			fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 50}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	handler := newProbeHandler(func(target Target) *CopilotCollector {
+		return NewCopilotCollector("test-token", target.Organization, target.Team, target.Enterprise, WithBaseURL(api.URL))
+	})
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "org=test-org", expected: `github_copilot_active_users_total{day="2024-01-01",org="test-org",scope="org",team=""} 10`},
+		{query: "target=test-org", expected: `github_copilot_active_users_total{day="2024-01-01",org="test-org",scope="org",team=""} 10`},
+		{query: "org=test-org&team=platform", expected: `github_copilot_active_users_total{day="2024-01-01",org="test-org",scope="team",team="platform"} 4`},
+		{query: "enterprise=test-enterprise", expected: `github_copilot_active_users_total{day="2024-01-01",org="test-enterprise",scope="enterprise",team=""} 50`},
+		{query: "org=unknown-org", expected: `github_copilot_scrape_success{org="unknown-org",scope="org",team=""} 0`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			code, body := probe(t, handler, tt.query)
+			if code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", code, body)
+			}
+			if !strings.Contains(body, tt.expected) {
+				t.Errorf("Expected %s in probe output, got:\n%s", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestProbeHandler_InvalidTarget(t *testing.T) {
+	handler := newProbeHandler(func(target Target) *CopilotCollector {
+		t.Errorf("Unexpected collector for invalid target %s", target)
+		return nil
+	})
+
+	for _, query := range []string{"", "team=platform", "org=a&enterprise=b", "org=a&target=b"} {
+		if code, _ := probe(t, handler, query); code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, code)
+		}
+	}
+}
+
+func TestProbeHandler_NotStored(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 10}]`)
+	}))
+	defer api.Close()
+
+	store := openTestStore(t, 0)
+	cfg := &config{githubToken: "test-token", opts: []CollectorOption{WithBaseURL(api.URL), WithStore(store)}}
+
+	if code, body := probe(t, newProbeHandler(cfg.newProbeCollector), "org=test-org"); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", code, body)
+	}
+
+	stored, err := store.Load(Target{Organization: "test-org"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(stored) != 0 {
+		t.Errorf("Expected probes not to be stored, got %v", days(stored))
+	}
+}
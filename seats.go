@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// idleSeatDays are the idle_days buckets of github_copilot_seats_idle
+var idleSeatDays = []int{7, 14, 30, 60}
+
+// CopilotBilling represents the Copilot billing information of an organization
+type CopilotBilling struct {
+	SeatBreakdown struct {
+		Total               int `json:"total"`
+		AddedThisCycle      int `json:"added_this_cycle"`
+		PendingInvitation   int `json:"pending_invitation"`
+		PendingCancellation int `json:"pending_cancellation"`
+		ActiveThisCycle     int `json:"active_this_cycle"`
+		InactiveThisCycle   int `json:"inactive_this_cycle"`
+	} `json:"seat_breakdown"`
+	SeatManagementSetting string `json:"seat_management_setting"`
+	PlanType              string `json:"plan_type,omitempty"`
+}
+
+// CopilotSeat represents a Copilot seat assigned to a user
+type CopilotSeat struct {
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+	PendingCancellationDate string     `json:"pending_cancellation_date,omitempty"`
+	LastActivityAt          *time.Time `json:"last_activity_at,omitempty"`
+	LastActivityEditor      string     `json:"last_activity_editor,omitempty"`
+	PlanType                string     `json:"plan_type,omitempty"`
+
+	Assignee struct {
+		Login string `json:"login"`
+		Type  string `json:"type,omitempty"`
+	} `json:"assignee"`
+	AssigningTeam *struct {
+		Slug string `json:"slug"`
+		Name string `json:"name,omitempty"`
+	} `json:"assigning_team,omitempty"`
+
+	// Only set in enterprise seat listings
+	Organization *struct {
+		Login string `json:"login"`
+	} `json:"organization,omitempty"`
+}
+
+// copilotSeatsPage is a page of the Copilot seats API
+type copilotSeatsPage struct {
+	Seats []CopilotSeat `json:"seats"`
+}
+
+// seatSnapshot holds the billing information and seat list fetched together. Enterprises have no
+// billing information, only seats.
+type seatSnapshot struct {
+	billing *CopilotBilling
+	seats   []CopilotSeat
+}
+
+// seatsSupported reports whether seats are fetched for the collector's target; the seats APIs only
+// exist for organizations and enterprises
+func (c *CopilotCollector) seatsSupported() bool {
+	return c.seatsEnabled && c.team == ""
+}
+
+// fetchBilling retrieves the Copilot billing information of the organization
+func (c *CopilotCollector) fetchBilling() (CopilotBilling, error) {
+	var billing CopilotBilling
+	apiURL := fmt.Sprintf("%s/orgs/%s/copilot/billing", c.baseURL, url.PathEscape(c.organization))
+	if _, err := c.getJSON(apiURL, &billing); err != nil {
+		return CopilotBilling{}, err
+	}
+	return billing, nil
+}
+
+// fetchSeats lists all Copilot seats of the organization or enterprise, following Link pagination
+func (c *CopilotCollector) fetchSeats() ([]CopilotSeat, error) {
+	apiURL := fmt.Sprintf("%s/orgs/%s/copilot/billing/seats?per_page=%d", c.baseURL, url.PathEscape(c.organization), maxPerPage)
+	if c.enterprise != "" {
+		apiURL = fmt.Sprintf("%s/enterprises/%s/copilot/billing/seats?per_page=%d", c.baseURL, url.PathEscape(c.enterprise), maxPerPage)
+	}
+
+	var seats []CopilotSeat
+	for apiURL != "" {
+		var page copilotSeatsPage
+		next, err := c.getJSON(apiURL, &page)
+		if err != nil {
+			return nil, err
+		}
+		seats = append(seats, page.Seats...)
+		apiURL = next
+	}
+
+	return seats, nil
+}
+
+// refreshSeats fetches billing information, for organizations, and seats and replaces the seat snapshot
+// on success, keeping the previous one on error
+func (c *CopilotCollector) refreshSeats() error {
+	var billing *CopilotBilling
+	var err error
+	if c.enterprise == "" {
+		var b CopilotBilling
+		b, err = c.fetchBilling()
+		billing = &b
+	}
+	var seats []CopilotSeat
+	if err == nil {
+		seats, err = c.fetchSeats()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seatsErr = err
+	if err != nil {
+		return err
+	}
+	c.seats = &seatSnapshot{billing: billing, seats: seats}
+	c.seatsTime = time.Now()
+
+	return nil
+}
+
+// currentSeats returns the last successfully fetched seats, when they were fetched and the most recent fetch error
+func (c *CopilotCollector) currentSeats() (*seatSnapshot, time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.seats, c.seatsTime, c.seatsErr
+}
+
+// collectSeats exports the seat and billing metrics of the current seat snapshot
+func (c *CopilotCollector) collectSeats(ch chan<- prometheus.Metric) {
+	if !c.seatsSupported() {
+		return
+	}
+
+	snapshot, updated, err := c.currentSeats()
+
+	success := 0.0
+	if err == nil && !updated.IsZero() {
+		success = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.seatsScrapeSuccess, prometheus.GaugeValue, success)
+
+	if updated.IsZero() || c.isStale(updated) {
+		return
+	}
+
+	if snapshot.billing != nil {
+		breakdown := snapshot.billing.SeatBreakdown
+		ch <- prometheus.MustNewConstMetric(c.seatsTotal, prometheus.GaugeValue, float64(breakdown.Total))
+		ch <- prometheus.MustNewConstMetric(c.seatsActive, prometheus.GaugeValue, float64(breakdown.ActiveThisCycle))
+		ch <- prometheus.MustNewConstMetric(c.seatsInactive, prometheus.GaugeValue, float64(breakdown.InactiveThisCycle))
+		ch <- prometheus.MustNewConstMetric(c.seatsPendingInvitation, prometheus.GaugeValue, float64(breakdown.PendingInvitation))
+		ch <- prometheus.MustNewConstMetric(c.seatsPendingCancellation, prometheus.GaugeValue, float64(breakdown.PendingCancellation))
+		ch <- prometheus.MustNewConstMetric(c.seatsAddedThisCycle, prometheus.GaugeValue, float64(breakdown.AddedThisCycle))
+		ch <- prometheus.MustNewConstMetric(
+			c.billingInfo,
+			prometheus.GaugeValue,
+			1,
+			labelOrUnknown(snapshot.billing.PlanType), labelOrUnknown(snapshot.billing.SeatManagementSetting),
+		)
+	}
+
+	for plan, count := range snapshot.seatsByPlan() {
+		ch <- prometheus.MustNewConstMetric(c.seatsAssigned, prometheus.GaugeValue, float64(count), plan)
+		if price, ok := c.seatPrices[plan]; ok {
+			ch <- prometheus.MustNewConstMetric(c.seatsMonthlyCost, prometheus.GaugeValue, float64(count)*price, plan)
+		}
+	}
+
+	users := snapshot.users()
+	idle, never := idleSeats(users, time.Now())
+	for i, days := range idleSeatDays {
+		ch <- prometheus.MustNewConstMetric(c.seatsIdle, prometheus.GaugeValue, float64(idle[i]), strconv.Itoa(days))
+	}
+	ch <- prometheus.MustNewConstMetric(c.seatsIdle, prometheus.GaugeValue, float64(never), "never")
+
+	byEditor := make(map[string]int)
+	for _, seat := range users {
+		if seat.LastActivityAt != nil {
+			byEditor[labelOrUnknown(seat.editor())]++
+		}
+	}
+	for editor, count := range byEditor {
+		ch <- prometheus.MustNewConstMetric(c.seatsLastActivityEditor, prometheus.GaugeValue, float64(count), editor)
+	}
+
+	if c.enterprise != "" {
+		c.collectOrganizationSeats(ch, snapshot.seats)
+	}
+}
+
+// collectOrganizationSeats exports the seats of an enterprise aggregated by the organization granting them
+func (c *CopilotCollector) collectOrganizationSeats(ch chan<- prometheus.Metric, seats []CopilotSeat) {
+	byOrg := make(map[string][]CopilotSeat)
+	for _, seat := range seats {
+		org := labelOrUnknown(seat.organization())
+		byOrg[org] = append(byOrg[org], seat)
+	}
+
+	now := time.Now()
+	for org, orgSeats := range byOrg {
+		ch <- prometheus.MustNewConstMetric(c.organizationSeatsAssigned, prometheus.GaugeValue, float64(len(orgSeats)), org)
+
+		pending := 0
+		for _, seat := range orgSeats {
+			if seat.PendingCancellationDate != "" {
+				pending++
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(c.organizationSeatsPendingCancellation, prometheus.GaugeValue, float64(pending), org)
+
+		idle, never := idleSeats(orgSeats, now)
+		for i, days := range idleSeatDays {
+			ch <- prometheus.MustNewConstMetric(c.organizationSeatsIdle, prometheus.GaugeValue, float64(idle[i]), org, strconv.Itoa(days))
+		}
+		ch <- prometheus.MustNewConstMetric(c.organizationSeatsIdle, prometheus.GaugeValue, float64(never), org, "never")
+	}
+}
+
+// users returns one seat per assignee. Enterprise listings repeat a user once per organization granting
+// them a seat, but the user is billed once; the seat with the most recent activity is kept.
+func (s *seatSnapshot) users() []CopilotSeat {
+	users := make([]CopilotSeat, 0, len(s.seats))
+	index := make(map[string]int)
+	for _, seat := range s.seats {
+		login := seat.Assignee.Login
+		i, ok := index[login]
+		if !ok || login == "" {
+			index[login] = len(users)
+			users = append(users, seat)
+			continue
+		}
+		if seat.LastActivityAt != nil && (users[i].LastActivityAt == nil || seat.LastActivityAt.After(*users[i].LastActivityAt)) {
+			users[i] = seat
+		}
+	}
+	return users
+}
+
+// seatsByPlan counts the users by plan type; seats without one, or with GitHub's "unknown", count toward
+// the organization's plan
+func (s *seatSnapshot) seatsByPlan() map[string]int {
+	byPlan := make(map[string]int)
+	for _, seat := range s.users() {
+		plan := seat.PlanType
+		if (plan == "" || plan == "unknown") && s.billing != nil {
+			plan = s.billing.PlanType
+		}
+		byPlan[labelOrUnknown(plan)]++
+	}
+	return byPlan
+}
+
+// monthlySeatCost returns the monthly cost of the current seats, or false when no seats were fetched
+// or a plan in use has no configured price
+func (c *CopilotCollector) monthlySeatCost() (float64, bool) {
+	snapshot, updated, _ := c.currentSeats()
+	if !c.seatsSupported() || len(c.seatPrices) == 0 || updated.IsZero() || c.isStale(updated) {
+		return 0, false
+	}
+
+	cost := 0.0
+	for plan, count := range snapshot.seatsByPlan() {
+		price, ok := c.seatPrices[plan]
+		if !ok {
+			return 0, false
+		}
+		cost += float64(count) * price
+	}
+	return cost, true
+}
+
+// collectCost exports the seat cost per active user and per accepted line of each day. Both divide the
+// day's share of the current monthly seat cost, spread evenly over the year.
+func (c *CopilotCollector) collectCost(ch chan<- prometheus.Metric, metrics CopilotAPIResponse) {
+	monthlyCost, ok := c.monthlySeatCost()
+	if !ok {
+		return
+	}
+	dailyCost := monthlyCost * 12 / 365
+
+	for _, metric := range metrics {
+		if metric.TotalActiveUsers > 0 {
+			ch <- c.dailyMetric(
+				c.costPerActiveUser,
+				prometheus.GaugeValue,
+				dailyCost/float64(metric.TotalActiveUsers),
+				metric.Day,
+			)
+		}
+		if metric.TotalLinesAccepted > 0 {
+			ch <- c.dailyMetric(
+				c.costPerAcceptedLine,
+				prometheus.GaugeValue,
+				dailyCost/float64(metric.TotalLinesAccepted),
+				metric.Day,
+			)
+		}
+	}
+}
+
+// idleSeats counts the seats without activity for at least each of idleSeatDays days before now,
+// never used seats included, and the never used seats alone
+func idleSeats(seats []CopilotSeat, now time.Time) ([]int, int) {
+	idle := make([]int, len(idleSeatDays))
+	never := 0
+	for _, seat := range seats {
+		if seat.LastActivityAt == nil {
+			never++
+		}
+		for i, days := range idleSeatDays {
+			if seat.idleFor(days, now) {
+				idle[i]++
+			}
+		}
+	}
+	return idle, never
+}
+
+// idleFor reports whether the seat has had no activity for at least days days before now, or never had any
+func (s CopilotSeat) idleFor(days int, now time.Time) bool {
+	return s.LastActivityAt == nil || !s.LastActivityAt.After(now.AddDate(0, 0, -days))
+}
+
+// organization returns the login of the organization granting the seat, which only enterprise listings report
+func (s CopilotSeat) organization() string {
+	if s.Organization == nil {
+		return ""
+	}
+	return s.Organization.Login
+}
+
+// editor returns the editor name of the seat's last activity, which GitHub reports as
+// editor/version/plugin/version, e.g. vscode/1.85.0/copilot/1.150.0
+func (s CopilotSeat) editor() string {
+	name, _, _ := strings.Cut(s.LastActivityEditor, "/")
+	return name
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testBillingResponse = `{
+	"seat_breakdown": {
+		"total": 3,
+		"added_this_cycle": 1,
+		"pending_invitation": 1,
+		"pending_cancellation": 1,
+		"active_this_cycle": 2,
+		"inactive_this_cycle": 1
+	},
+	"seat_management_setting": "assign_selected",
+	"plan_type": "business"
+}`
+
+// newSeatsServer serves the billing API and two pages of seats for test-org, and metrics for every scope
+func newSeatsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/test-org/copilot/billing":
+			fmt.Fprint(w, testBillingResponse)
+		case "/orgs/test-org/copilot/billing/seats":
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `{"seats": [
+					{"created_at": "2024-01-01T00:00:00Z", "plan_type": "enterprise", "assignee": {"login": "carol"}}
+				]}`)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/test-org/copilot/billing/seats?per_page=100&page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `{"seats": [
+				{"created_at": "2024-01-01T00:00:00Z", "last_activity_at": "2024-02-01T10:00:00Z", "last_activity_editor": "vscode/1.85.0/copilot/1.150.0", "plan_type": "business", "assignee": {"login": "alice"}, "assigning_team": {"slug": "platform"}},
+				{"created_at": "2024-01-02T00:00:00Z", "pending_cancellation_date": "2024-03-01", "plan_type": "business", "assignee": {"login": "bob"}}
+			]}`)
+		default:
+			fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 2, "total_lines_accepted": 6}]`)
+		}
+	}))
+	return server
+}
+
+func TestCopilotCollector_FetchSeats_Pagination(t *testing.T) {
+	server := newSeatsServer(t)
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	seats, err := collector.fetchSeats()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var logins []string
+	for _, seat := range seats {
+		logins = append(logins, seat.Assignee.Login)
+	}
+	if strings.Join(logins, ",") != "alice,bob,carol" {
+		t.Errorf("Expected alice,bob,carol, got %v", logins)
+	}
+	if seats[0].LastActivityAt == nil || seats[0].AssigningTeam == nil || seats[0].AssigningTeam.Slug != "platform" {
+		t.Errorf("Expected last activity and assigning team for alice, got %+v", seats[0])
+	}
+	if seats[1].LastActivityAt != nil || seats[1].PendingCancellationDate != "2024-03-01" {
+		t.Errorf("Expected no activity and a pending cancellation for bob, got %+v", seats[1])
+	}
+}
+
+func TestCopilotCollector_Collect_Seats(t *testing.T) {
+	server := newSeatsServer(t)
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL), WithSeats(true))
+
+	expected := `
+# HELP github_copilot_billing_info Copilot plan and seat management setting of the organization, always 1
+# TYPE github_copilot_billing_info gauge
+github_copilot_billing_info{org="test-org",plan_type="business",scope="org",seat_management_setting="assign_selected",team=""} 1
+# HELP github_copilot_seats_active Copilot seats used in the current billing cycle
+# TYPE github_copilot_seats_active gauge
+github_copilot_seats_active{org="test-org",scope="org",team=""} 2
+# HELP github_copilot_seats_added_this_cycle Copilot seats added in the current billing cycle
+# TYPE github_copilot_seats_added_this_cycle gauge
+github_copilot_seats_added_this_cycle{org="test-org",scope="org",team=""} 1
+# HELP github_copilot_seats_assigned Copilot seats listed by the seats API by plan type
+# TYPE github_copilot_seats_assigned gauge
+github_copilot_seats_assigned{org="test-org",plan_type="business",scope="org",team=""} 2
+github_copilot_seats_assigned{org="test-org",plan_type="enterprise",scope="org",team=""} 1
+# HELP github_copilot_seats_inactive Copilot seats not used in the current billing cycle
+# TYPE github_copilot_seats_inactive gauge
+github_copilot_seats_inactive{org="test-org",scope="org",team=""} 1
+# HELP github_copilot_seats_last_activity_editor Copilot seats by the editor of their last activity
+# TYPE github_copilot_seats_last_activity_editor gauge
+github_copilot_seats_last_activity_editor{editor="vscode",org="test-org",scope="org",team=""} 1
+# HELP github_copilot_seats_pending_cancellation Copilot seats that will be removed at the end of the current billing cycle
+# TYPE github_copilot_seats_pending_cancellation gauge
+github_copilot_seats_pending_cancellation{org="test-org",scope="org",team=""} 1
+# HELP github_copilot_seats_pending_invitation Copilot seats assigned to users who have not accepted their organization invitation
+# TYPE github_copilot_seats_pending_invitation gauge
+github_copilot_seats_pending_invitation{org="test-org",scope="org",team=""} 1
+# HELP github_copilot_seats_scrape_success Whether the most recent fetch from the GitHub Copilot billing API succeeded (1) or failed (0)
+# TYPE github_copilot_seats_scrape_success gauge
+github_copilot_seats_scrape_success{org="test-org",scope="org",team=""} 1
+# HELP github_copilot_seats_total Total number of Copilot seats billed in the current billing cycle
+# TYPE github_copilot_seats_total gauge
+github_copilot_seats_total{org="test-org",scope="org",team=""} 3
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_billing_info",
+		"github_copilot_seats_active",
+		"github_copilot_seats_added_this_cycle",
+		"github_copilot_seats_assigned",
+		"github_copilot_seats_inactive",
+		"github_copilot_seats_last_activity_editor",
+		"github_copilot_seats_pending_cancellation",
+		"github_copilot_seats_pending_invitation",
+		"github_copilot_seats_scrape_success",
+		"github_copilot_seats_total",
+	)
+	if err != nil {
+		t.Errorf("Unexpected seat metrics: %v", err)
+	}
+}
+
+func TestCopilotCollector_Collect_SeatsErrorKeepsMetrics(t *testing.T) {
+	var mu sync.Mutex
+	billingStatus := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/orgs/test-org/copilot/billing":
+			w.WriteHeader(billingStatus)
+			fmt.Fprint(w, testBillingResponse)
+		case "/orgs/test-org/copilot/billing/seats":
+			fmt.Fprint(w, `{"seats": []}`)
+		default:
+			fmt.Fprint(w, `[{"day": "2024-01-01", "total_active_users": 2}]`)
+		}
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL), WithSeats(true))
+	if err := collector.refresh(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The billing API starts failing; the metrics and the last seat snapshot are still served
+	mu.Lock()
+	billingStatus = http.StatusForbidden
+	mu.Unlock()
+	if err := collector.refresh(); err != nil {
+		t.Fatalf("Expected seat errors not to fail the refresh, got %v", err)
+	}
+
+	expected := `
+# HELP github_copilot_scrape_success Whether the most recent fetch from the GitHub API succeeded (1) or failed (0)
+# TYPE github_copilot_scrape_success gauge
+github_copilot_scrape_success{org="test-org",scope="org",team=""} 1
+# HELP github_copilot_seats_scrape_success Whether the most recent fetch from the GitHub Copilot billing API succeeded (1) or failed (0)
+# TYPE github_copilot_seats_scrape_success gauge
+github_copilot_seats_scrape_success{org="test-org",scope="org",team=""} 0
+# HELP github_copilot_seats_total Total number of Copilot seats billed in the current billing cycle
+# TYPE github_copilot_seats_total gauge
+github_copilot_seats_total{org="test-org",scope="org",team=""} 3
+`
+	collector.pollInterval = defaultPollInterval
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_scrape_success",
+		"github_copilot_seats_scrape_success",
+		"github_copilot_seats_total",
+	)
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+func TestCopilotCollector_Collect_Cost(t *testing.T) {
+	server := newSeatsServer(t)
+	defer server.Close()
+
+	// Two business and one enterprise seat cost 365 a month, 12 a day
+	prices := map[string]float64{"business": 100, "enterprise": 165}
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL), WithSeats(true), WithSeatPrices(prices))
+
+	expected := `
+# HELP github_copilot_cost_per_accepted_line Daily share of the Copilot seat cost divided by the lines accepted that day
+# TYPE github_copilot_cost_per_accepted_line gauge
+github_copilot_cost_per_accepted_line{day="2024-01-01",org="test-org",scope="org",team=""} 2
+# HELP github_copilot_cost_per_active_user Daily share of the Copilot seat cost divided by the active users of that day
+# TYPE github_copilot_cost_per_active_user gauge
+github_copilot_cost_per_active_user{day="2024-01-01",org="test-org",scope="org",team=""} 6
+# HELP github_copilot_seats_monthly_cost Monthly cost of the Copilot seats by plan type, at the configured seat price
+# TYPE github_copilot_seats_monthly_cost gauge
+github_copilot_seats_monthly_cost{org="test-org",plan_type="business",scope="org",team=""} 200
+github_copilot_seats_monthly_cost{org="test-org",plan_type="enterprise",scope="org",team=""} 165
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_cost_per_accepted_line",
+		"github_copilot_cost_per_active_user",
+		"github_copilot_seats_monthly_cost",
+	)
+	if err != nil {
+		t.Errorf("Unexpected cost metrics: %v", err)
+	}
+}
+
+func TestCopilotCollector_Collect_CostMissingPrice(t *testing.T) {
+	server := newSeatsServer(t)
+	defer server.Close()
+
+	// Without an enterprise price the total cost is unknown, so only the business cost is exported
+	prices := map[string]float64{"business": 100}
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL), WithSeats(true), WithSeatPrices(prices))
+
+	expected := `
+# HELP github_copilot_seats_monthly_cost Monthly cost of the Copilot seats by plan type, at the configured seat price
+# TYPE github_copilot_seats_monthly_cost gauge
+github_copilot_seats_monthly_cost{org="test-org",plan_type="business",scope="org",team=""} 200
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_cost_per_accepted_line",
+		"github_copilot_cost_per_active_user",
+		"github_copilot_seats_monthly_cost",
+	)
+	if err != nil {
+		t.Errorf("Unexpected cost metrics: %v", err)
+	}
+}
+
+func TestSeatSnapshot_SeatsByPlan(t *testing.T) {
+	seats := []CopilotSeat{{PlanType: "enterprise"}, {PlanType: "unknown"}, {}}
+
+	// Seats without a known plan count toward the organization's plan
+	org := &seatSnapshot{billing: &CopilotBilling{PlanType: "business"}, seats: seats}
+	if got := org.seatsByPlan(); len(got) != 2 || got["business"] != 2 || got["enterprise"] != 1 {
+		t.Errorf("Expected 2 business and 1 enterprise seats, got %v", got)
+	}
+
+	// Enterprises have no billing plan to fall back to
+	enterprise := &seatSnapshot{seats: seats}
+	if got := enterprise.seatsByPlan(); len(got) != 2 || got["unknown"] != 2 || got["enterprise"] != 1 {
+		t.Errorf("Expected 2 unknown and 1 enterprise seats, got %v", got)
+	}
+}
+
+func TestCopilotCollector_SeatsSupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector *CopilotCollector
+		expected  bool
+	}{
+		{name: "org", collector: NewCopilotCollector("", "test-org", "", "", WithSeats(true)), expected: true},
+		{name: "disabled", collector: NewCopilotCollector("", "test-org", "", ""), expected: false},
+		{name: "team", collector: NewCopilotCollector("", "test-org", "platform", "", WithSeats(true)), expected: false},
+		{name: "enterprise", collector: NewCopilotCollector("", "", "", "test-enterprise", WithSeats(true)), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.collector.seatsSupported(); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// newEnterpriseSeatsServer serves two pages of seats across two organizations for test-enterprise; alice
+// has a seat from both
+func newEnterpriseSeatsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	lastActivity := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/enterprises/test-enterprise/copilot/billing/seats":
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `{"seats": [
+					{"created_at": "2024-01-01T00:00:00Z", "plan_type": "business", "assignee": {"login": "carol"}, "organization": {"login": "acme-data"}},
+					{"created_at": "2024-01-03T00:00:00Z", "plan_type": "enterprise", "assignee": {"login": "alice"}, "organization": {"login": "acme-data"}}
+				]}`)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/enterprises/test-enterprise/copilot/billing/seats?per_page=100&page=2>; rel="next"`, server.URL))
+			fmt.Fprintf(w, `{"seats": [
+				{"created_at": "2024-01-01T00:00:00Z", "last_activity_at": %q, "last_activity_editor": "vscode/1.85.0/copilot/1.150.0", "plan_type": "enterprise", "assignee": {"login": "alice"}, "organization": {"login": "acme-web"}},
+				{"created_at": "2024-01-02T00:00:00Z", "pending_cancellation_date": "2024-03-01", "plan_type": "enterprise", "assignee": {"login": "bob"}, "organization": {"login": "acme-web"}}
+			]}`, lastActivity)
+		case "/enterprises/test-enterprise/copilot/metrics":
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func TestCopilotCollector_Collect_EnterpriseSeats(t *testing.T) {
+	server := newEnterpriseSeatsServer(t)
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "", "", "test-enterprise", WithBaseURL(server.URL), WithSeats(true),
+		WithSeatPrices(map[string]float64{"business": 19, "enterprise": 39}))
+
+	// Per organization alice counts twice, enterprise-wide once, with her most recent activity
+	expected := `
+# HELP github_copilot_organization_seats_assigned Copilot seats of an enterprise by the organization granting them
+# TYPE github_copilot_organization_seats_assigned gauge
+github_copilot_organization_seats_assigned{org="test-enterprise",organization="acme-data",scope="enterprise",team=""} 2
+github_copilot_organization_seats_assigned{org="test-enterprise",organization="acme-web",scope="enterprise",team=""} 2
+# HELP github_copilot_organization_seats_idle Copilot seats of an enterprise without activity for at least idle_days days by the organization granting them; idle_days=never counts only never used seats
+# TYPE github_copilot_organization_seats_idle gauge
+github_copilot_organization_seats_idle{idle_days="14",org="test-enterprise",organization="acme-data",scope="enterprise",team=""} 2
+github_copilot_organization_seats_idle{idle_days="14",org="test-enterprise",organization="acme-web",scope="enterprise",team=""} 1
+github_copilot_organization_seats_idle{idle_days="30",org="test-enterprise",organization="acme-data",scope="enterprise",team=""} 2
+github_copilot_organization_seats_idle{idle_days="30",org="test-enterprise",organization="acme-web",scope="enterprise",team=""} 1
+github_copilot_organization_seats_idle{idle_days="60",org="test-enterprise",organization="acme-data",scope="enterprise",team=""} 2
+github_copilot_organization_seats_idle{idle_days="60",org="test-enterprise",organization="acme-web",scope="enterprise",team=""} 1
+github_copilot_organization_seats_idle{idle_days="7",org="test-enterprise",organization="acme-data",scope="enterprise",team=""} 2
+github_copilot_organization_seats_idle{idle_days="7",org="test-enterprise",organization="acme-web",scope="enterprise",team=""} 1
+github_copilot_organization_seats_idle{idle_days="never",org="test-enterprise",organization="acme-data",scope="enterprise",team=""} 2
+github_copilot_organization_seats_idle{idle_days="never",org="test-enterprise",organization="acme-web",scope="enterprise",team=""} 1
+# HELP github_copilot_organization_seats_pending_cancellation Copilot seats of an enterprise pending cancellation by the organization granting them
+# TYPE github_copilot_organization_seats_pending_cancellation gauge
+github_copilot_organization_seats_pending_cancellation{org="test-enterprise",organization="acme-data",scope="enterprise",team=""} 0
+github_copilot_organization_seats_pending_cancellation{org="test-enterprise",organization="acme-web",scope="enterprise",team=""} 1
+# HELP github_copilot_seats_assigned Copilot seats listed by the seats API by plan type
+# TYPE github_copilot_seats_assigned gauge
+github_copilot_seats_assigned{org="test-enterprise",plan_type="business",scope="enterprise",team=""} 1
+github_copilot_seats_assigned{org="test-enterprise",plan_type="enterprise",scope="enterprise",team=""} 2
+# HELP github_copilot_seats_idle Copilot seats without activity for at least idle_days days, including never used seats; idle_days=never counts only those
+# TYPE github_copilot_seats_idle gauge
+github_copilot_seats_idle{idle_days="14",org="test-enterprise",scope="enterprise",team=""} 2
+github_copilot_seats_idle{idle_days="30",org="test-enterprise",scope="enterprise",team=""} 2
+github_copilot_seats_idle{idle_days="60",org="test-enterprise",scope="enterprise",team=""} 2
+github_copilot_seats_idle{idle_days="7",org="test-enterprise",scope="enterprise",team=""} 2
+github_copilot_seats_idle{idle_days="never",org="test-enterprise",scope="enterprise",team=""} 2
+# HELP github_copilot_seats_last_activity_editor Copilot seats by the editor of their last activity
+# TYPE github_copilot_seats_last_activity_editor gauge
+github_copilot_seats_last_activity_editor{editor="vscode",org="test-enterprise",scope="enterprise",team=""} 1
+# HELP github_copilot_seats_monthly_cost Monthly cost of the Copilot seats by plan type, at the configured seat price
+# TYPE github_copilot_seats_monthly_cost gauge
+github_copilot_seats_monthly_cost{org="test-enterprise",plan_type="business",scope="enterprise",team=""} 19
+github_copilot_seats_monthly_cost{org="test-enterprise",plan_type="enterprise",scope="enterprise",team=""} 78
+# HELP github_copilot_seats_scrape_success Whether the most recent fetch from the GitHub Copilot billing API succeeded (1) or failed (0)
+# TYPE github_copilot_seats_scrape_success gauge
+github_copilot_seats_scrape_success{org="test-enterprise",scope="enterprise",team=""} 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"github_copilot_billing_info",
+		"github_copilot_organization_seats_assigned",
+		"github_copilot_organization_seats_idle",
+		"github_copilot_organization_seats_pending_cancellation",
+		"github_copilot_seats_assigned",
+		"github_copilot_seats_idle",
+		"github_copilot_seats_last_activity_editor",
+		"github_copilot_seats_monthly_cost",
+		"github_copilot_seats_scrape_success",
+		"github_copilot_seats_total",
+	)
+	if err != nil {
+		t.Errorf("Unexpected enterprise seat metrics: %v", err)
+	}
+}
+
+func TestIdleSeats(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	activeDaysAgo := func(days int) CopilotSeat {
+		last := now.AddDate(0, 0, -days)
+		return CopilotSeat{LastActivityAt: &last}
+	}
+	seats := []CopilotSeat{
+		activeDaysAgo(0),
+		activeDaysAgo(7),
+		activeDaysAgo(20),
+		activeDaysAgo(45),
+		activeDaysAgo(90),
+		{},
+	}
+
+	idle, never := idleSeats(seats, now)
+	expected := []int{5, 4, 3, 2}
+	for i, days := range idleSeatDays {
+		if idle[i] != expected[i] {
+			t.Errorf("Expected %d seats idle for %d days, got %d", expected[i], days, idle[i])
+		}
+	}
+	if never != 1 {
+		t.Errorf("Expected 1 never used seat, got %d", never)
+	}
+}
+
+func TestCopilotSeat_Editor(t *testing.T) {
+	tests := map[string]string{
+		"vscode/1.85.0/copilot/1.150.0": "vscode",
+		"JetBrains-IC/233.11799.241":    "JetBrains-IC",
+		"vim":                           "vim",
+		"":                              "",
+	}
+	for lastActivityEditor, expected := range tests {
+		if got := (CopilotSeat{LastActivityEditor: lastActivityEditor}).editor(); got != expected {
+			t.Errorf("%q: expected %q, got %q", lastActivityEditor, expected, got)
+		}
+	}
+}
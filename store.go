@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store persists daily metrics on disk, one bucket per target keyed by day, so history outlives
+// GitHub's retention window
+type Store struct {
+	db *bolt.DB
+
+	// Days older than this are deleted; zero keeps everything
+	retentionDays int
+	now           func() time.Time
+}
+
+// OpenStore opens or creates the store at path
+func OpenStore(path string, retentionDays int) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening store %s: %w", path, err)
+	}
+	return &Store{db: db, retentionDays: retentionDays, now: time.Now}, nil
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// storeKey identifies a target's bucket; unlike Target.String it must never change
+func storeKey(t Target) string {
+	switch t.Scope() {
+	case "enterprise":
+		return "enterprises/" + t.Enterprise
+	case "team":
+		return "orgs/" + t.Organization + "/teams/" + t.Team
+	default:
+		return "orgs/" + t.Organization
+	}
+}
+
+// cutoff returns the oldest day kept, or an empty string when retention is unlimited
+func (s *Store) cutoff() string {
+	if s.retentionDays <= 0 {
+		return ""
+	}
+	return s.now().UTC().AddDate(0, 0, -s.retentionDays).Format(time.DateOnly)
+}
+
+// Upsert stores every day of metrics for target, replacing days already stored, and deletes days past retention
+func (s *Store) Upsert(target Target, metrics CopilotAPIResponse) error {
+	cutoff := s.cutoff()
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(storeKey(target)))
+		if err != nil {
+			return err
+		}
+
+		for _, day := range metrics {
+			if day.Day == "" || day.Day < cutoff {
+				continue
+			}
+			value, err := json.Marshal(day)
+			if err != nil {
+				return fmt.Errorf("error encoding day %s: %w", day.Day, err)
+			}
+			if err := bucket.Put([]byte(day.Day), value); err != nil {
+				return err
+			}
+		}
+
+		if cutoff == "" {
+			return nil
+		}
+		// Keys are YYYY-MM-DD, so expired days come first
+		cursor := bucket.Cursor()
+		for k, _ := cursor.First(); k != nil && string(k) < cutoff; k, _ = cursor.First() {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Load returns all stored days of target within retention, oldest first
+func (s *Store) Load(target Target) (CopilotAPIResponse, error) {
+	cutoff := s.cutoff()
+
+	var metrics CopilotAPIResponse
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(storeKey(target)))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		for k, v := cursor.Seek([]byte(cutoff)); k != nil; k, v = cursor.Next() {
+			var day CopilotDayMetrics
+			if err := json.Unmarshal(v, &day); err != nil {
+				return fmt.Errorf("error decoding day %s: %w", k, err)
+			}
+			metrics = append(metrics, day)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, retentionDays int) *Store {
+	t.Helper()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "copilot.db"), retentionDays)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func days(metrics CopilotAPIResponse) []string {
+	var result []string
+	for _, day := range metrics {
+		result = append(result, day.Day)
+	}
+	return result
+}
+
+func TestStore_UpsertAndLoad(t *testing.T) {
+	store := openTestStore(t, 0)
+	org := Target{Organization: "test-org"}
+	team := Target{Organization: "test-org", Team: "platform"}
+
+	if err := store.Upsert(org, CopilotAPIResponse{{Day: "2024-01-02", TotalActiveUsers: 12}, {Day: "2024-01-01", TotalActiveUsers: 10}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// A later fetch revises 2024-01-02 and adds 2024-01-03
+	if err := store.Upsert(org, CopilotAPIResponse{{Day: "2024-01-02", TotalActiveUsers: 13}, {Day: "2024-01-03", TotalActiveUsers: 14}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.Upsert(team, CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 4}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	metrics, err := store.Load(org)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := days(metrics); len(got) != 3 || got[0] != "2024-01-01" || got[2] != "2024-01-03" {
+		t.Fatalf("Expected three days in order, got %v", got)
+	}
+	if metrics[1].TotalActiveUsers != 13 {
+		t.Errorf("Expected revised value 13, got %d", metrics[1].TotalActiveUsers)
+	}
+
+	metrics, err = store.Load(team)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].TotalActiveUsers != 4 {
+		t.Errorf("Expected the team's own day, got %+v", metrics)
+	}
+
+	metrics, err = store.Load(Target{Enterprise: "unknown"})
+	if err != nil || len(metrics) != 0 {
+		t.Errorf("Expected no days for an unknown target, got %v (%v)", days(metrics), err)
+	}
+}
+
+func TestStore_Retention(t *testing.T) {
+	store := openTestStore(t, 30)
+	store.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	org := Target{Organization: "test-org"}
+
+	if err := store.Upsert(org, CopilotAPIResponse{{Day: "2024-01-15"}, {Day: "2024-02-10"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A month later 2024-02-10 has expired too and is deleted by the next upsert
+	store.now = func() time.Time { return time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC) }
+	if err := store.Upsert(org, CopilotAPIResponse{{Day: "2024-03-19"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	metrics, err := store.Load(org)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := days(metrics); len(got) != 1 || got[0] != "2024-03-19" {
+		t.Errorf("Expected only 2024-03-19, got %v", got)
+	}
+}
+
+func TestCopilotCollector_Refresh_WithStore(t *testing.T) {
+	store := openTestStore(t, 0)
+
+	fetched := CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 10}, {Day: "2024-01-02", TotalActiveUsers: 12}}
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithStore(store))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return fetched, nil
+	}
+
+	if err := collector.refresh(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// GitHub has dropped 2024-01-01 from its window, but the store still has it
+	fetched = CopilotAPIResponse{{Day: "2024-01-02", TotalActiveUsers: 12}, {Day: "2024-01-03", TotalActiveUsers: 14}}
+	if err := collector.refresh(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// /metrics keeps serving the API's window, the stored history is available separately
+	snapshot, _, _ := collector.currentSnapshot()
+	if got := days(snapshot); len(got) != 2 || got[0] != "2024-01-02" {
+		t.Errorf("Expected the fetched window from 2024-01-02, got %v", got)
+	}
+	if got := days(collector.storedHistory(snapshot)); len(got) != 3 || got[0] != "2024-01-01" {
+		t.Errorf("Expected stored history from 2024-01-01, got %v", got)
+	}
+}
+
+func TestCopilotCollector_Refresh_WithStoredHistory(t *testing.T) {
+	store := openTestStore(t, 0)
+	if err := store.Upsert(Target{Organization: "test-org"}, CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 10}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithStore(store), WithStoredHistory(true))
+	collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return CopilotAPIResponse{{Day: "2024-01-02", TotalActiveUsers: 12}}, nil
+	}
+
+	if err := collector.refresh(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	snapshot, _, _ := collector.currentSnapshot()
+	if got := days(snapshot); len(got) != 2 || got[0] != "2024-01-01" {
+		t.Errorf("Expected stored history from 2024-01-01, got %v", got)
+	}
+}
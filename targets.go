@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// reservedLabels are label names already used by the collector's metrics
+var reservedLabels = map[string]bool{
+	"day":                        true,
+	"org":                        true,
+	"team":                       true,
+	"scope":                      true,
+	"editor":                     true,
+	"model":                      true,
+	"language":                   true,
+	"repository":                 true,
+	"is_custom_model":            true,
+	"custom_model_training_date": true,
+	"plan_type":                  true,
+	"seat_management_setting":    true,
+	"idle_days":                  true,
+	"organization":               true,
+}
+
+// Target is an organization, team or enterprise whose Copilot metrics are exported
+type Target struct {
+	Organization string `json:"org"`
+	Team         string `json:"team"`
+	Enterprise   string `json:"enterprise"`
+
+	// Token overrides the exporter-wide credentials for this target; TokenEnv names an environment variable holding it
+	Token    string `json:"token"`
+	TokenEnv string `json:"token_env"`
+
+	// Extra constant labels added to every series of this target
+	Labels map[string]string `json:"labels"`
+
+	// DiscoverTeams also exports every team of the organization whose slug matches TeamInclude and not TeamExclude
+	DiscoverTeams bool   `json:"discover_teams"`
+	TeamInclude   string `json:"team_include"`
+	TeamExclude   string `json:"team_exclude"`
+}
+
+// Scope returns the scope label value: enterprise, org or team
+func (t Target) Scope() string {
+	switch {
+	case t.Enterprise != "":
+		return "enterprise"
+	case t.Team != "":
+		return "team"
+	default:
+		return "org"
+	}
+}
+
+// String identifies the target in log messages
+func (t Target) String() string {
+	switch t.Scope() {
+	case "enterprise":
+		return "enterprise " + t.Enterprise
+	case "team":
+		return "org " + t.Organization + " team " + t.Team
+	default:
+		return "org " + t.Organization
+	}
+}
+
+// validate checks that the target names exactly one scope and uses no reserved label names
+func (t Target) validate() error {
+	if t.Organization == "" && t.Enterprise == "" {
+		return fmt.Errorf("target needs org or enterprise")
+	}
+	if t.Organization != "" && t.Enterprise != "" {
+		return fmt.Errorf("target %s: org and enterprise are mutually exclusive", t)
+	}
+	if t.Team != "" && t.Organization == "" {
+		return fmt.Errorf("target %s: team requires org", t)
+	}
+	if t.Token != "" && t.TokenEnv != "" {
+		return fmt.Errorf("target %s: token and token_env are mutually exclusive", t)
+	}
+	if t.DiscoverTeams && (t.Organization == "" || t.Team != "") {
+		return fmt.Errorf("target %s: discover_teams requires org without team", t)
+	}
+	if _, err := newTeamFilter(t.TeamInclude, t.TeamExclude); err != nil {
+		return fmt.Errorf("target %s: %w", t, err)
+	}
+	for name := range t.Labels {
+		if reservedLabels[name] {
+			return fmt.Errorf("target %s: label %q is reserved", t, name)
+		}
+	}
+	return nil
+}
+
+// resolveToken returns the target's own token, or an empty string to use the exporter-wide credentials
+func (t Target) resolveToken() (string, error) {
+	if t.TokenEnv == "" {
+		return t.Token, nil
+	}
+	token := os.Getenv(t.TokenEnv)
+	if token == "" {
+		return "", fmt.Errorf("target %s: environment variable %s is empty", t, t.TokenEnv)
+	}
+	return token, nil
+}
+
+// loadTargets reads a JSON array of targets from path and validates it
+func loadTargets(path string) ([]Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading targets file: %w", err)
+	}
+
+	var targets []Target
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("error parsing targets file: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("targets file %s lists no targets", path)
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		if err := t.validate(); err != nil {
+			return nil, err
+		}
+		if seen[t.String()] {
+			return nil, fmt.Errorf("target %s is listed more than once", t)
+		}
+		seen[t.String()] = true
+	}
+	return targets, nil
+}
+
+// targetLabels returns the extra constant labels for each target. Every target gets the same label names,
+// with extra labels missing from a target set to empty, since Prometheus requires consistent
+// label names within a metric family.
+func targetLabels(targets []Target) []prometheus.Labels {
+	var extra []string
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		for name := range t.Labels {
+			if !seen[name] {
+				seen[name] = true
+				extra = append(extra, name)
+			}
+		}
+	}
+	sort.Strings(extra)
+
+	labels := make([]prometheus.Labels, len(targets))
+	for i, t := range targets {
+		labels[i] = prometheus.Labels{}
+		for _, name := range extra {
+			labels[i][name] = t.Labels[name]
+		}
+	}
+	return labels
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func writeTargetsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "targets.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTargets(t *testing.T) {
+	path := writeTargetsFile(t, `[
+		{"org": "org-a"},
+		{"org": "org-a", "team": "platform", "token_env": "PLATFORM_TOKEN", "labels": {"env": "prod"}},
+		{"enterprise": "acme", "token": "enterprise-token"}
+	]`)
+
+	targets, err := loadTargets(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(targets) != 3 {
+		t.Fatalf("Expected 3 targets, got %d", len(targets))
+	}
+
+	scopes := []string{targets[0].Scope(), targets[1].Scope(), targets[2].Scope()}
+	if strings.Join(scopes, ",") != "org,team,enterprise" {
+		t.Errorf("Unexpected scopes %v", scopes)
+	}
+	if targets[1].Labels["env"] != "prod" {
+		t.Errorf("Expected env label prod, got %q", targets[1].Labels["env"])
+	}
+}
+
+func TestLoadTargets_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "empty list", content: `[]`, wantErr: "no targets"},
+		{name: "no scope", content: `[{"team": "platform"}]`, wantErr: "needs org or enterprise"},
+		{name: "org and enterprise", content: `[{"org": "a", "enterprise": "b"}]`, wantErr: "mutually exclusive"},
+		{name: "team without org", content: `[{"enterprise": "b", "team": "c"}]`, wantErr: "team requires org"},
+		{name: "duplicate", content: `[{"org": "a"}, {"org": "a"}]`, wantErr: "more than once"},
+		{name: "reserved label", content: `[{"org": "a", "labels": {"day": "x"}}]`, wantErr: "reserved"},
+		{name: "reserved seat label", content: `[{"org": "a", "labels": {"plan_type": "x"}}]`, wantErr: "reserved"},
+		{name: "reserved idle seat label", content: `[{"org": "a", "labels": {"idle_days": "x"}}]`, wantErr: "reserved"},
+		{name: "reserved organization label", content: `[{"enterprise": "a", "labels": {"organization": "x"}}]`, wantErr: "reserved"},
+		{name: "discovery with team", content: `[{"org": "a", "team": "b", "discover_teams": true}]`, wantErr: "discover_teams requires org"},
+		{name: "invalid team pattern", content: `[{"org": "a", "discover_teams": true, "team_include": "("}]`, wantErr: "invalid team include pattern"},
+		{name: "malformed", content: `{`, wantErr: "error parsing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTargets(writeTargetsFile(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTarget_ResolveToken(t *testing.T) {
+	t.Setenv("PLATFORM_TOKEN", "platform-token")
+
+	token, err := Target{Organization: "a", TokenEnv: "PLATFORM_TOKEN"}.resolveToken()
+	if err != nil || token != "platform-token" {
+		t.Errorf("Expected platform-token, got %q (%v)", token, err)
+	}
+
+	if _, err := (Target{Organization: "a", TokenEnv: "MISSING_TOKEN"}).resolveToken(); err == nil {
+		t.Error("Expected error for empty token environment variable")
+	}
+}
+
+func TestTargetLabels(t *testing.T) {
+	labels := targetLabels([]Target{
+		{Organization: "a", Labels: map[string]string{"env": "prod"}},
+		{Organization: "a", Team: "platform", Labels: map[string]string{"region": "eu"}},
+	})
+
+	expected := []prometheus.Labels{
+		{"env": "prod", "region": ""},
+		{"env": "", "region": "eu"},
+	}
+	for i := range expected {
+		if fmt.Sprint(labels[i]) != fmt.Sprint(expected[i]) {
+			t.Errorf("Target %d: expected %v, got %v", i, expected[i], labels[i])
+		}
+	}
+}
+
+// Targets of the same scope can be registered side by side, and a failing target must not hide the metrics of the others
+func TestMultipleTargets_FailureIsolation(t *testing.T) {
+	targets := []Target{
+		{Organization: "org-a"},
+		{Organization: "org-b"},
+	}
+	labels := targetLabels(targets)
+
+	healthy := NewCopilotCollector("test-token", "org-a", "", "")
+	healthy.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 10}}, nil
+	}
+	failing := NewCopilotCollector("test-token", "org-b", "", "")
+	failing.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return nil, fmt.Errorf("API request failed with status 404")
+	}
+
+	reg := prometheus.NewRegistry()
+	for i, collector := range []*CopilotCollector{healthy, failing} {
+		if err := prometheus.WrapRegistererWith(labels[i], reg).Register(collector); err != nil {
+			t.Fatalf("Error registering target %s: %v", targets[i], err)
+		}
+	}
+
+	expected := `
+# HELP github_copilot_active_users_total Total number of active Copilot users
+# TYPE github_copilot_active_users_total gauge
+github_copilot_active_users_total{day="2024-01-01",org="org-a",scope="org",team=""} 10
+# HELP github_copilot_scrape_success Whether the most recent fetch from the GitHub API succeeded (1) or failed (0)
+# TYPE github_copilot_scrape_success gauge
+github_copilot_scrape_success{org="org-a",scope="org",team=""} 1
+github_copilot_scrape_success{org="org-b",scope="org",team=""} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "github_copilot_active_users_total", "github_copilot_scrape_success"); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
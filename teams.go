@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultTeamDiscoveryInterval = 24 * time.Hour
+
+// fetchTeams lists the slugs of all teams in the collector's organization, following Link pagination
+func (c *CopilotCollector) fetchTeams() ([]string, error) {
+	apiURL := fmt.Sprintf("%s/orgs/%s/teams?per_page=%d", c.baseURL, url.PathEscape(c.organization), maxPerPage)
+
+	var slugs []string
+	for apiURL != "" {
+		var teams []struct {
+			Slug string `json:"slug"`
+		}
+		next, err := c.getJSON(apiURL, &teams)
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			slugs = append(slugs, team.Slug)
+		}
+		apiURL = next
+	}
+
+	return slugs, nil
+}
+
+// teamFilter selects discovered teams by slug; a nil regexp matches everything for include and nothing for exclude
+type teamFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// newTeamFilter compiles the include and exclude patterns, either of which may be empty
+func newTeamFilter(include, exclude string) (teamFilter, error) {
+	var f teamFilter
+	var err error
+	if include != "" {
+		if f.include, err = regexp.Compile(include); err != nil {
+			return teamFilter{}, fmt.Errorf("invalid team include pattern: %w", err)
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return teamFilter{}, fmt.Errorf("invalid team exclude pattern: %w", err)
+		}
+	}
+	return f, nil
+}
+
+func (f teamFilter) match(slug string) bool {
+	if f.include != nil && !f.include.MatchString(slug) {
+		return false
+	}
+	return f.exclude == nil || !f.exclude.MatchString(slug)
+}
+
+// discoveredTeam is a registered per-team collector and the cancel func of its poller
+type discoveredTeam struct {
+	collector *CopilotCollector
+	cancel    context.CancelFunc
+}
+
+// TeamDiscoverer periodically lists an organization's teams and keeps one registered collector per matching team
+type TeamDiscoverer struct {
+	org        *CopilotCollector
+	filter     teamFilter
+	interval   time.Duration
+	registerer prometheus.Registerer
+	labels     prometheus.Labels
+
+	// Builds the collector for a newly discovered team
+	newCollector func(team string) *CopilotCollector
+
+	mu    sync.Mutex
+	teams map[string]discoveredTeam
+}
+
+// NewTeamDiscoverer creates a discoverer for the organization of org. Per-team collectors are registered
+// with registerer, wrapped with the target's extra labels.
+func NewTeamDiscoverer(org *CopilotCollector, filter teamFilter, interval time.Duration, registerer prometheus.Registerer, labels prometheus.Labels, newCollector func(team string) *CopilotCollector) *TeamDiscoverer {
+	return &TeamDiscoverer{
+		org:          org,
+		filter:       filter,
+		interval:     interval,
+		registerer:   registerer,
+		labels:       labels,
+		newCollector: newCollector,
+		teams:        make(map[string]discoveredTeam),
+	}
+}
+
+// Run discovers teams immediately and then every interval until ctx is cancelled
+func (d *TeamDiscoverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.refresh(ctx); err != nil {
+			log.Printf("Error discovering teams for %s: %v", d.org.target(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			d.mu.Lock()
+			for slug := range d.teams {
+				d.remove(slug)
+			}
+			d.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh registers collectors for new matching teams and unregisters those of teams that are gone.
+// On error the current set of teams is kept.
+func (d *TeamDiscoverer) refresh(ctx context.Context) error {
+	slugs, err := d.org.fetchTeams()
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := make(map[string]bool)
+	for _, slug := range slugs {
+		if !d.filter.match(slug) {
+			continue
+		}
+		current[slug] = true
+		if _, ok := d.teams[slug]; ok {
+			continue
+		}
+
+		collector := d.newCollector(slug)
+		if err := prometheus.WrapRegistererWith(d.labels, d.registerer).Register(collector); err != nil {
+			log.Printf("Error registering discovered team %s: %v", collector.target(), err)
+			continue
+		}
+
+		pollCtx, cancel := context.WithCancel(ctx)
+		if collector.pollInterval > 0 {
+			go collector.Poll(pollCtx)
+		}
+		d.teams[slug] = discoveredTeam{collector: collector, cancel: cancel}
+		log.Printf("Discovered %s", collector.target())
+	}
+
+	for slug := range d.teams {
+		if !current[slug] {
+			log.Printf("Removing %s", d.teams[slug].collector.target())
+			d.remove(slug)
+		}
+	}
+
+	return nil
+}
+
+// Collectors returns the collectors of the currently discovered teams, sorted by slug
+func (d *TeamDiscoverer) Collectors() []*CopilotCollector {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	slugs := make([]string, 0, len(d.teams))
+	for slug := range d.teams {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	collectors := make([]*CopilotCollector, len(slugs))
+	for i, slug := range slugs {
+		collectors[i] = d.teams[slug].collector
+	}
+	return collectors
+}
+
+// remove stops and unregisters the collector of a discovered team; d.mu must be held
+func (d *TeamDiscoverer) remove(slug string) {
+	team := d.teams[slug]
+	team.cancel()
+	prometheus.WrapRegistererWith(d.labels, d.registerer).Unregister(team.collector)
+	delete(d.teams, slug)
+}
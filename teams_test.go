@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCopilotCollector_FetchTeams_Pagination(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/test-org/teams" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Expected Authorization header 'Bearer test-token'")
+		}
+
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"slug": "mobile"}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/test-org/teams?per_page=100&page=2>; rel="next"`, server.URL))
+		fmt.Fprint(w, `[{"slug": "platform"}, {"slug": "web"}]`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	slugs, err := collector.fetchTeams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(slugs, ",") != "platform,web,mobile" {
+		t.Errorf("Expected platform,web,mobile, got %v", slugs)
+	}
+}
+
+func TestCopilotCollector_FetchTeams_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	}))
+	defer server.Close()
+
+	collector := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+
+	if _, err := collector.fetchTeams(); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Expected status 404 error, got %v", err)
+	}
+}
+
+func TestTeamFilter(t *testing.T) {
+	filter, err := newTeamFilter("^(platform|web)", "-bots$")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := map[string]bool{
+		"platform":      true,
+		"web-frontend":  true,
+		"platform-bots": false,
+		"mobile":        false,
+	}
+	for slug, expected := range tests {
+		if got := filter.match(slug); got != expected {
+			t.Errorf("%s: expected %v, got %v", slug, expected, got)
+		}
+	}
+
+	if _, err := newTeamFilter("(", ""); err == nil {
+		t.Error("Expected error for invalid include pattern")
+	}
+}
+
+func TestTeamDiscoverer_Refresh(t *testing.T) {
+	var mu sync.Mutex
+	teams := `[{"slug": "platform"}, {"slug": "web"}, {"slug": "bots"}]`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprint(w, teams)
+	}))
+	defer server.Close()
+
+	org := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+	filter, _ := newTeamFilter("", "^bots$")
+	newCollector := func(team string) *CopilotCollector {
+		collector := NewCopilotCollector("test-token", "test-org", team, "")
+		collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+			return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: len(team)}}, nil
+		}
+		return collector
+	}
+
+	reg := prometheus.NewRegistry()
+	discoverer := NewTeamDiscoverer(org, filter, defaultTeamDiscoveryInterval, reg, prometheus.Labels{"env": "prod"}, newCollector)
+
+	if err := discoverer.refresh(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `
+# HELP github_copilot_active_users_total Total number of active Copilot users
+# TYPE github_copilot_active_users_total gauge
+github_copilot_active_users_total{day="2024-01-01",env="prod",org="test-org",scope="team",team="platform"} 8
+github_copilot_active_users_total{day="2024-01-01",env="prod",org="test-org",scope="team",team="web"} 3
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "github_copilot_active_users_total"); err != nil {
+		t.Errorf("Unexpected metrics after first discovery: %v", err)
+	}
+
+	// web is deleted and mobile is created
+	mu.Lock()
+	teams = `[{"slug": "platform"}, {"slug": "mobile"}]`
+	mu.Unlock()
+
+	if err := discoverer.refresh(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected = `
+# HELP github_copilot_active_users_total Total number of active Copilot users
+# TYPE github_copilot_active_users_total gauge
+github_copilot_active_users_total{day="2024-01-01",env="prod",org="test-org",scope="team",team="mobile"} 6
+github_copilot_active_users_total{day="2024-01-01",env="prod",org="test-org",scope="team",team="platform"} 8
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "github_copilot_active_users_total"); err != nil {
+		t.Errorf("Unexpected metrics after second discovery: %v", err)
+	}
+
+	collectors := discoverer.Collectors()
+	if len(collectors) != 2 || collectors[0].team != "mobile" || collectors[1].team != "platform" {
+		t.Errorf("Expected the mobile and platform collectors, got %d collectors", len(collectors))
+	}
+}
+
+// The organization's collector and the collectors of its discovered teams are registered side by side
+func TestTeamDiscoverer_RegistersAlongsideOrganization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"slug": "platform"}, {"slug": "web"}]`)
+	}))
+	defer server.Close()
+
+	labels := targetLabels([]Target{{Organization: "test-org", DiscoverTeams: true}})
+
+	org := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+	org.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+		return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: 20}}, nil
+	}
+	newCollector := func(team string) *CopilotCollector {
+		collector := NewCopilotCollector("test-token", "test-org", team, "")
+		collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+			return CopilotAPIResponse{{Day: "2024-01-01", TotalActiveUsers: len(team)}}, nil
+		}
+		return collector
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := prometheus.WrapRegistererWith(labels[0], reg).Register(org); err != nil {
+		t.Fatalf("Error registering organization: %v", err)
+	}
+	discoverer := NewTeamDiscoverer(org, teamFilter{}, defaultTeamDiscoveryInterval, reg, labels[0], newCollector)
+	if err := discoverer.refresh(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(discoverer.teams) != 2 {
+		t.Fatalf("Expected 2 registered teams, got %d", len(discoverer.teams))
+	}
+
+	expected := `
+# HELP github_copilot_active_users_total Total number of active Copilot users
+# TYPE github_copilot_active_users_total gauge
+github_copilot_active_users_total{day="2024-01-01",org="test-org",scope="org",team=""} 20
+github_copilot_active_users_total{day="2024-01-01",org="test-org",scope="team",team="platform"} 8
+github_copilot_active_users_total{day="2024-01-01",org="test-org",scope="team",team="web"} 3
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "github_copilot_active_users_total"); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+func TestTeamDiscoverer_RefreshErrorKeepsTeams(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `[{"slug": "platform"}]`)
+	}))
+	defer server.Close()
+
+	org := NewCopilotCollector("test-token", "test-org", "", "", WithBaseURL(server.URL))
+	newCollector := func(team string) *CopilotCollector {
+		collector := NewCopilotCollector("test-token", "test-org", team, "")
+		collector.testMetricsFetcher = func() (CopilotAPIResponse, error) {
+			return CopilotAPIResponse{{Day: "2024-01-01"}}, nil
+		}
+		return collector
+	}
+
+	reg := prometheus.NewRegistry()
+	discoverer := NewTeamDiscoverer(org, teamFilter{}, defaultTeamDiscoveryInterval, reg, nil, newCollector)
+
+	if err := discoverer.refresh(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fail = true
+	if err := discoverer.refresh(context.Background()); err == nil {
+		t.Fatal("Expected error when listing teams fails")
+	}
+	if len(discoverer.teams) != 1 {
+		t.Errorf("Expected the discovered team to be kept, got %d teams", len(discoverer.teams))
+	}
+}